@@ -0,0 +1,53 @@
+package archive
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/archive"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/spf13/cobra"
+)
+
+func query(b *boiler.Boiler) *cobra.Command {
+	flags := &rangeFlags{}
+	var url string
+
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Print archived clicks as NDJSON",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to, err := flags.parse()
+			if err != nil {
+				return err
+			}
+			var id *uuid.UUID
+			if url != "" {
+				parsed, err := uuid.Parse(url)
+				if err != nil {
+					return err
+				}
+				id = &parsed
+			}
+
+			enc := json.NewEncoder(os.Stdout)
+			return boiler.MustResolve[*archive.Archiver](b).Query(
+				cmd.Context(),
+				from,
+				to,
+				func(click *archive.Click) error {
+					if id != nil && click.UrlID != *id {
+						return nil
+					}
+					return enc.Encode(click)
+				},
+			)
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().StringVar(&url, "url", "", "Only print clicks for the url with this id")
+
+	return cmd
+}
@@ -0,0 +1,35 @@
+package archive
+
+import (
+	"fmt"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/archive"
+	"github.com/spf13/cobra"
+)
+
+func restore(b *boiler.Boiler) *cobra.Command {
+	flags := &rangeFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore archived clicks into the database",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to, err := flags.parse()
+			if err != nil {
+				return err
+			}
+
+			restored, err := boiler.MustResolve[*archive.Archiver](b).Restore(cmd.Context(), from, to)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("restored %d clicks\n", restored)
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
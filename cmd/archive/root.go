@@ -0,0 +1,61 @@
+package archive
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func New(b *boiler.Boiler) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "archive",
+		Short:   "Query and restore archived clicks",
+		GroupID: "app",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			app.RegisterBase(b)
+			b.MustBootstrap()
+		},
+	}
+
+	cmd.AddCommand(query(b))
+	cmd.AddCommand(restore(b))
+
+	return cmd
+}
+
+type rangeFlags struct {
+	from string
+	to   string
+}
+
+func (r *rangeFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&r.from, "from", "", "The start of the range, as a date or RFC3339 time")
+	cmd.Flags().StringVar(&r.to, "to", "", "The end of the range, as a date or RFC3339 time (exclusive)")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+}
+
+func (r *rangeFlags) parse() (time.Time, time.Time, error) {
+	from, err := parseTime(r.from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+	}
+	to, err := parseTime(r.to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+func parseTime(in string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, in); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, in)
+}
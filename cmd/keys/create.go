@@ -0,0 +1,26 @@
+package keys
+
+import (
+	"fmt"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/spf13/cobra"
+)
+
+func create(b *boiler.Boiler) *cobra.Command {
+	return &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a new api key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, token, err := boiler.MustResolve[*apikeys.Keys](b).Create(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("id:    %s\n", key.ID)
+			fmt.Printf("token: %s\n", token)
+			return nil
+		},
+	}
+}
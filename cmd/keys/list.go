@@ -0,0 +1,43 @@
+package keys
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/spf13/cobra"
+)
+
+func list(b *boiler.Boiler) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List api keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keys, err := boiler.MustResolve[*apikeys.Keys](b).List(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tCREATED\tREVOKED")
+			for _, key := range keys {
+				revoked := ""
+				if key.RevokedAt != nil {
+					revoked = key.RevokedAt.Format(time.RFC3339)
+				}
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%s\t%s\n",
+					key.ID,
+					key.Name,
+					key.CreatedAt.Format(time.RFC3339),
+					revoked,
+				)
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -0,0 +1,23 @@
+package keys
+
+import (
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/spf13/cobra"
+)
+
+func revoke(b *boiler.Boiler) *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke [id]",
+		Short: "Revoke an api key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := uuid.Parse(args[0])
+			if err != nil {
+				return err
+			}
+			return boiler.MustResolve[*apikeys.Keys](b).Revoke(cmd.Context(), id)
+		},
+	}
+}
@@ -0,0 +1,25 @@
+package keys
+
+import (
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func New(b *boiler.Boiler) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "keys",
+		Short:   "Manage api keys",
+		GroupID: "app",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			app.RegisterBase(b)
+			b.MustBootstrap()
+		},
+	}
+
+	cmd.AddCommand(create(b))
+	cmd.AddCommand(list(b))
+	cmd.AddCommand(revoke(b))
+
+	return cmd
+}
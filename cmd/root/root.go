@@ -2,7 +2,9 @@ package root
 
 import (
 	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/cmd/archive"
 	"github.com/henrywhitaker3/shorturl/cmd/consume"
+	"github.com/henrywhitaker3/shorturl/cmd/keys"
 	"github.com/henrywhitaker3/shorturl/cmd/migrate"
 	"github.com/henrywhitaker3/shorturl/cmd/routes"
 	"github.com/henrywhitaker3/shorturl/cmd/secrets"
@@ -33,6 +35,8 @@ func New(b *boiler.Boiler) *cobra.Command {
 	cmd.AddCommand(routes.New(b))
 	cmd.AddCommand(consume.New(b))
 	cmd.AddCommand(seed.New(b))
+	cmd.AddCommand(keys.New(b))
+	cmd.AddCommand(archive.New(b))
 	cmd.AddCommand(secrets.New())
 
 	cmd.PersistentFlags().
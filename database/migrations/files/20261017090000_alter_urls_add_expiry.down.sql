@@ -0,0 +1,4 @@
+-- reverse: create index "idx_urls_expires_at" to table: "urls"
+DROP INDEX "public"."idx_urls_expires_at";
+-- reverse: modify "urls" table
+ALTER TABLE "public"."urls" DROP COLUMN "visits", DROP COLUMN "max_clicks", DROP COLUMN "expires_at";
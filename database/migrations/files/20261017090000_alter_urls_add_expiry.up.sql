@@ -0,0 +1,4 @@
+-- modify "urls" table
+ALTER TABLE "public"."urls" ADD COLUMN "expires_at" bigint NULL, ADD COLUMN "max_clicks" bigint NULL, ADD COLUMN "visits" bigint NOT NULL DEFAULT 0;
+-- create index "idx_urls_expires_at" to table: "urls"
+CREATE INDEX "idx_urls_expires_at" ON "public"."urls" ("expires_at");
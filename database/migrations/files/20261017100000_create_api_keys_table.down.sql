@@ -0,0 +1,8 @@
+-- reverse: create index "idx_urls_owner_id" to table: "urls"
+DROP INDEX "public"."idx_urls_owner_id";
+-- reverse: modify "urls" table
+ALTER TABLE "public"."urls" DROP CONSTRAINT "fk_urls_owner_id", DROP COLUMN "owner_id";
+-- reverse: create index "idx_api_keys_hash" to table: "api_keys"
+DROP INDEX "public"."idx_api_keys_hash";
+-- reverse: create "api_keys" table
+DROP TABLE "public"."api_keys";
@@ -0,0 +1,15 @@
+-- create "api_keys" table
+CREATE TABLE "public"."api_keys" (
+  "id" uuid NOT NULL,
+  "name" text NOT NULL,
+  "hash" text NOT NULL,
+  "created_at" bigint NOT NULL,
+  "revoked_at" bigint NULL,
+  PRIMARY KEY ("id")
+);
+-- create index "idx_api_keys_hash" to table: "api_keys"
+CREATE UNIQUE INDEX "idx_api_keys_hash" ON "public"."api_keys" ("hash");
+-- modify "urls" table
+ALTER TABLE "public"."urls" ADD COLUMN "owner_id" uuid NULL, ADD CONSTRAINT "fk_urls_owner_id" FOREIGN KEY ("owner_id") REFERENCES "public"."api_keys" ("id") ON UPDATE NO ACTION ON DELETE SET NULL;
+-- create index "idx_urls_owner_id" to table: "urls"
+CREATE INDEX "idx_urls_owner_id" ON "public"."urls" ("owner_id");
@@ -0,0 +1,2 @@
+-- reverse: create index "idx_urls_url" to table: "urls"
+DROP INDEX "public"."idx_urls_url";
@@ -0,0 +1,2 @@
+-- create index "idx_urls_url" to table: "urls"
+CREATE INDEX "idx_urls_url" ON "public"."urls" USING HASH ("url");
@@ -0,0 +1,2 @@
+-- reverse: modify "urls" table
+ALTER TABLE "public"."urls" DROP COLUMN "quarantine_reason", DROP COLUMN "quarantined_at";
@@ -0,0 +1,2 @@
+-- modify "urls" table
+ALTER TABLE "public"."urls" ADD COLUMN "quarantined_at" bigint NULL, ADD COLUMN "quarantine_reason" text NULL;
@@ -0,0 +1,4 @@
+-- reverse: create index "idx_clicks_url_id_clicked_at" to table: "clicks"
+DROP INDEX "public"."idx_clicks_url_id_clicked_at";
+-- reverse: drop index "idx_clicks_url_id" from table: "clicks"
+CREATE INDEX "idx_clicks_url_id" ON "public"."clicks" ("url_id");
@@ -0,0 +1,4 @@
+-- drop index "idx_clicks_url_id" from table: "clicks"
+DROP INDEX "public"."idx_clicks_url_id";
+-- create index "idx_clicks_url_id_clicked_at" to table: "clicks"
+CREATE INDEX "idx_clicks_url_id_clicked_at" ON "public"."clicks" ("url_id", "clicked_at");
@@ -0,0 +1,2 @@
+-- reverse: modify "clicks" table
+ALTER TABLE "public"."clicks" DROP COLUMN "bot", DROP COLUMN "device", DROP COLUMN "os", DROP COLUMN "browser", DROP COLUMN "language", DROP COLUMN "user_agent", DROP COLUMN "referrer_domain", DROP COLUMN "referrer";
@@ -0,0 +1,2 @@
+-- modify "clicks" table
+ALTER TABLE "public"."clicks" ADD COLUMN "referrer" text NOT NULL DEFAULT '', ADD COLUMN "referrer_domain" text NOT NULL DEFAULT '', ADD COLUMN "user_agent" text NOT NULL DEFAULT '', ADD COLUMN "language" text NOT NULL DEFAULT '', ADD COLUMN "browser" text NOT NULL DEFAULT '', ADD COLUMN "os" text NOT NULL DEFAULT '', ADD COLUMN "device" text NOT NULL DEFAULT '', ADD COLUMN "bot" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+-- reverse: modify "clicks" table
+ALTER TABLE "public"."clicks" DROP COLUMN "city", DROP COLUMN "region", DROP COLUMN "country";
@@ -0,0 +1,2 @@
+-- modify "clicks" table
+ALTER TABLE "public"."clicks" ADD COLUMN "country" text NOT NULL DEFAULT '', ADD COLUMN "region" text NOT NULL DEFAULT '', ADD COLUMN "city" text NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- reverse: create index "idx_clicks_clicked_at" to table: "clicks"
+DROP INDEX "public"."idx_clicks_clicked_at";
+-- reverse: create "click_rollups_breakdown" table
+DROP TABLE "public"."click_rollups_breakdown";
+-- reverse: create "click_rollups_daily" table
+DROP TABLE "public"."click_rollups_daily";
+-- reverse: create "click_rollups_hourly" table
+DROP TABLE "public"."click_rollups_hourly";
@@ -0,0 +1,55 @@
+-- create "click_rollups_hourly" table
+CREATE TABLE "public"."click_rollups_hourly" (
+  "url_id" uuid NOT NULL,
+  "bucket" bigint NOT NULL,
+  "clicks" bigint NOT NULL,
+  "unique_ips" bigint NOT NULL,
+  PRIMARY KEY ("url_id", "bucket"),
+  CONSTRAINT "fk_click_rollups_hourly_url_id" FOREIGN KEY ("url_id") REFERENCES "public"."urls" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- create "click_rollups_daily" table
+CREATE TABLE "public"."click_rollups_daily" (
+  "url_id" uuid NOT NULL,
+  "bucket" bigint NOT NULL,
+  "clicks" bigint NOT NULL,
+  "unique_ips" bigint NOT NULL,
+  PRIMARY KEY ("url_id", "bucket"),
+  CONSTRAINT "fk_click_rollups_daily_url_id" FOREIGN KEY ("url_id") REFERENCES "public"."urls" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- create "click_rollups_breakdown" table
+CREATE TABLE "public"."click_rollups_breakdown" (
+  "url_id" uuid NOT NULL,
+  "bucket" bigint NOT NULL,
+  "dimension" text NOT NULL,
+  "value" text NOT NULL,
+  "clicks" bigint NOT NULL,
+  PRIMARY KEY ("url_id", "bucket", "dimension", "value"),
+  CONSTRAINT "fk_click_rollups_breakdown_url_id" FOREIGN KEY ("url_id") REFERENCES "public"."urls" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- create index "idx_clicks_clicked_at" to table: "clicks"
+CREATE INDEX "idx_clicks_clicked_at" ON "public"."clicks" ("clicked_at");
+-- backfill the rollups from the existing clicks
+INSERT INTO "public"."click_rollups_hourly" ("url_id", "bucket", "clicks", "unique_ips")
+SELECT "url_id", ("clicked_at" / 3600) * 3600, count(*), count(DISTINCT "ip")
+FROM "public"."clicks"
+GROUP BY 1, 2;
+INSERT INTO "public"."click_rollups_daily" ("url_id", "bucket", "clicks", "unique_ips")
+SELECT "url_id", ("clicked_at" / 86400) * 86400, count(*), count(DISTINCT "ip")
+FROM "public"."clicks"
+GROUP BY 1, 2;
+INSERT INTO "public"."click_rollups_breakdown" ("url_id", "bucket", "dimension", "value", "clicks")
+SELECT "url_id", ("clicked_at" / 86400) * 86400, d."dimension", d."value", count(*)
+FROM "public"."clicks"
+CROSS JOIN LATERAL (
+  VALUES
+    ('referrer', "referrer_domain"),
+    ('browser', "browser"),
+    ('os', "os"),
+    ('device', "device"),
+    ('language', "language"),
+    ('country', "country"),
+    ('region', CASE WHEN "region" = '' THEN '' ELSE "region" || ', ' || "country" END),
+    ('city', CASE WHEN "city" = '' THEN '' ELSE "city" || ', ' || "country" END),
+    ('bot', "bot" :: text)
+) AS d ("dimension", "value")
+GROUP BY 1, 2, 3, 4;
@@ -0,0 +1,31 @@
+-- reverse: partition "clicks" table
+ALTER TABLE "public"."clicks" RENAME TO "clicks_partitioned";
+ALTER INDEX "public"."clicks_pkey" RENAME TO "clicks_partitioned_pkey";
+ALTER INDEX "public"."idx_clicks_url_id_clicked_at" RENAME TO "idx_clicks_partitioned_url_id_clicked_at";
+ALTER INDEX "public"."idx_clicks_clicked_at" RENAME TO "idx_clicks_partitioned_clicked_at";
+ALTER TABLE "public"."clicks_partitioned" RENAME CONSTRAINT "fk_clicks_url_id" TO "fk_clicks_partitioned_url_id";
+CREATE TABLE "public"."clicks" (
+  "id" uuid NOT NULL,
+  "url_id" uuid NOT NULL,
+  "ip" text NOT NULL,
+  "clicked_at" bigint NOT NULL,
+  "referrer" text NOT NULL DEFAULT '',
+  "referrer_domain" text NOT NULL DEFAULT '',
+  "user_agent" text NOT NULL DEFAULT '',
+  "language" text NOT NULL DEFAULT '',
+  "browser" text NOT NULL DEFAULT '',
+  "os" text NOT NULL DEFAULT '',
+  "device" text NOT NULL DEFAULT '',
+  "bot" boolean NOT NULL DEFAULT false,
+  "country" text NOT NULL DEFAULT '',
+  "region" text NOT NULL DEFAULT '',
+  "city" text NOT NULL DEFAULT '',
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_clicks_url_id" FOREIGN KEY ("url_id") REFERENCES "public"."urls" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+CREATE INDEX "idx_clicks_url_id_clicked_at" ON "public"."clicks" ("url_id", "clicked_at");
+CREATE INDEX "idx_clicks_clicked_at" ON "public"."clicks" ("clicked_at");
+INSERT INTO "public"."clicks" SELECT * FROM "public"."clicks_partitioned" ON CONFLICT ("id") DO NOTHING;
+DROP TABLE "public"."clicks_partitioned";
+DROP FUNCTION "public"."prune_clicks" (bigint);
+DROP FUNCTION "public"."create_clicks_partition" (bigint);
@@ -0,0 +1,91 @@
+-- rename the existing "clicks" table so it can be copied into the partitioned table
+ALTER TABLE "public"."clicks" RENAME TO "clicks_legacy";
+ALTER INDEX "public"."clicks_pkey" RENAME TO "clicks_legacy_pkey";
+ALTER INDEX "public"."idx_clicks_url_id_clicked_at" RENAME TO "idx_clicks_legacy_url_id_clicked_at";
+ALTER INDEX "public"."idx_clicks_clicked_at" RENAME TO "idx_clicks_legacy_clicked_at";
+ALTER TABLE "public"."clicks_legacy" RENAME CONSTRAINT "fk_clicks_url_id" TO "fk_clicks_legacy_url_id";
+-- create "clicks" table
+CREATE TABLE "public"."clicks" (
+  "id" uuid NOT NULL,
+  "url_id" uuid NOT NULL,
+  "ip" text NOT NULL,
+  "clicked_at" bigint NOT NULL,
+  "referrer" text NOT NULL DEFAULT '',
+  "referrer_domain" text NOT NULL DEFAULT '',
+  "user_agent" text NOT NULL DEFAULT '',
+  "language" text NOT NULL DEFAULT '',
+  "browser" text NOT NULL DEFAULT '',
+  "os" text NOT NULL DEFAULT '',
+  "device" text NOT NULL DEFAULT '',
+  "bot" boolean NOT NULL DEFAULT false,
+  "country" text NOT NULL DEFAULT '',
+  "region" text NOT NULL DEFAULT '',
+  "city" text NOT NULL DEFAULT '',
+  PRIMARY KEY ("id", "clicked_at"),
+  CONSTRAINT "fk_clicks_url_id" FOREIGN KEY ("url_id") REFERENCES "public"."urls" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+) PARTITION BY RANGE ("clicked_at");
+-- create index "idx_clicks_url_id_clicked_at" to table: "clicks"
+CREATE INDEX "idx_clicks_url_id_clicked_at" ON "public"."clicks" ("url_id", "clicked_at");
+-- create index "idx_clicks_clicked_at" to table: "clicks"
+CREATE INDEX "idx_clicks_clicked_at" ON "public"."clicks" ("clicked_at");
+-- create "clicks_default" partition for clicks outside of the daily partitions
+CREATE TABLE "public"."clicks_default" PARTITION OF "public"."clicks" DEFAULT;
+-- create function "create_clicks_partition", clicks already in the default
+-- partition for the day are moved into the new partition. The default
+-- partition is locked for the move, as attaching fails if clicks for the day
+-- are inserted into it in the meantime.
+CREATE FUNCTION "public"."create_clicks_partition" ("day" bigint) RETURNS void LANGUAGE plpgsql AS $$
+DECLARE
+  start bigint := ("day" / 86400) * 86400;
+  partition_name text := 'clicks_p' || to_char(to_timestamp(start) AT TIME ZONE 'UTC', 'YYYYMMDD');
+BEGIN
+  IF to_regclass(format('"public".%I', partition_name)) IS NOT NULL THEN
+    RETURN;
+  END IF;
+  EXECUTE format('CREATE TABLE "public".%I (LIKE "public"."clicks" INCLUDING DEFAULTS)', partition_name);
+  LOCK TABLE "public"."clicks_default" IN ACCESS EXCLUSIVE MODE;
+  EXECUTE format(
+    'WITH moved AS (DELETE FROM "public"."clicks_default" WHERE "clicked_at" >= %s AND "clicked_at" < %s RETURNING *) INSERT INTO "public".%I SELECT * FROM moved',
+    start, start + 86400, partition_name
+  );
+  EXECUTE format(
+    'ALTER TABLE "public"."clicks" ATTACH PARTITION "public".%I FOR VALUES FROM (%s) TO (%s)',
+    partition_name, start, start + 86400
+  );
+END;
+$$;
+-- create function "prune_clicks", drops the daily partitions that end
+-- before the cutoff and deletes the clicks before it from the default
+-- partition
+CREATE FUNCTION "public"."prune_clicks" ("before" bigint) RETURNS bigint LANGUAGE plpgsql AS $$
+DECLARE
+  part record;
+  dropped bigint := 0;
+BEGIN
+  FOR part IN
+    SELECT c."relname"
+    FROM "pg_catalog"."pg_inherits" i
+    JOIN "pg_catalog"."pg_class" c ON c."oid" = i."inhrelid"
+    WHERE i."inhparent" = '"public"."clicks"' :: regclass
+      AND c."relname" ~ '^clicks_p[0-9]{8}$'
+  LOOP
+    IF extract(epoch FROM to_date(substr(part."relname", 9), 'YYYYMMDD')) :: bigint + 86400 <= "before" THEN
+      EXECUTE format('DROP TABLE "public".%I', part."relname");
+      dropped := dropped + 1;
+    END IF;
+  END LOOP;
+  DELETE FROM "public"."clicks_default" WHERE "clicked_at" < "before";
+  RETURN dropped;
+END;
+$$;
+-- copy the existing clicks into daily partitions, and create the
+-- partitions for the next week
+INSERT INTO "public"."clicks" SELECT * FROM "public"."clicks_legacy";
+SELECT "public"."create_clicks_partition"("day")
+FROM generate_series(
+  (SELECT coalesce(min("clicked_at"), extract(epoch FROM now()) :: bigint) FROM "public"."clicks_legacy"),
+  extract(epoch FROM now()) :: bigint + 86400 * 7,
+  86400
+) AS "day";
+-- drop "clicks_legacy" table
+DROP TABLE "public"."clicks_legacy";
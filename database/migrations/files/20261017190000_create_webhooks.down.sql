@@ -0,0 +1,8 @@
+-- reverse: create index "idx_webhook_deliveries_webhook_id" to table: "webhook_deliveries"
+DROP INDEX "public"."idx_webhook_deliveries_webhook_id";
+-- reverse: create "webhook_deliveries" table
+DROP TABLE "public"."webhook_deliveries";
+-- reverse: create index "idx_webhooks_owner_id" to table: "webhooks"
+DROP INDEX "public"."idx_webhooks_owner_id";
+-- reverse: create "webhooks" table
+DROP TABLE "public"."webhooks";
@@ -0,0 +1,30 @@
+-- create "webhooks" table
+CREATE TABLE "public"."webhooks" (
+  "id" uuid NOT NULL,
+  "owner_id" uuid NULL,
+  "url" text NOT NULL,
+  "secret" text NOT NULL,
+  "events" text[] NOT NULL,
+  "created_at" bigint NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_webhooks_owner_id" FOREIGN KEY ("owner_id") REFERENCES "public"."api_keys" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- create index "idx_webhooks_owner_id" to table: "webhooks"
+CREATE INDEX "idx_webhooks_owner_id" ON "public"."webhooks" ("owner_id");
+-- create "webhook_deliveries" table
+CREATE TABLE "public"."webhook_deliveries" (
+  "id" uuid NOT NULL,
+  "webhook_id" uuid NOT NULL,
+  "event" text NOT NULL,
+  "payload" text NOT NULL,
+  "status" text NOT NULL,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "response_code" integer NULL,
+  "error" text NULL,
+  "created_at" bigint NOT NULL,
+  "updated_at" bigint NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_webhook_deliveries_webhook_id" FOREIGN KEY ("webhook_id") REFERENCES "public"."webhooks" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- create index "idx_webhook_deliveries_webhook_id" to table: "webhook_deliveries"
+CREATE INDEX "idx_webhook_deliveries_webhook_id" ON "public"."webhook_deliveries" ("webhook_id", "id");
@@ -0,0 +1,8 @@
+-- reverse: create "click_rollups_watermark" table
+DROP TABLE "public"."click_rollups_watermark";
+-- reverse: create index "idx_clicks_url_id_stored_at" to table: "clicks"
+DROP INDEX "public"."idx_clicks_url_id_stored_at";
+-- reverse: create index "idx_clicks_stored_at" to table: "clicks"
+DROP INDEX "public"."idx_clicks_stored_at";
+-- reverse: modify "clicks" table
+ALTER TABLE "public"."clicks" DROP COLUMN "stored_at";
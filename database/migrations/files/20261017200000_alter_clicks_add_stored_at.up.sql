@@ -0,0 +1,52 @@
+-- modify "clicks" table, recording when each click was stored in
+-- microseconds so the rollups can pick up clicks that arrive late
+ALTER TABLE "public"."clicks" ADD COLUMN "stored_at" bigint NOT NULL DEFAULT (extract(epoch FROM now()) * 1000000) :: bigint;
+-- create index "idx_clicks_stored_at" to table: "clicks"
+CREATE INDEX "idx_clicks_stored_at" ON "public"."clicks" ("stored_at");
+-- create index "idx_clicks_url_id_stored_at" to table: "clicks"
+CREATE INDEX "idx_clicks_url_id_stored_at" ON "public"."clicks" ("url_id", "stored_at");
+-- create "click_rollups_watermark" table, clicks stored before the
+-- watermark have been rolled up
+CREATE TABLE "public"."click_rollups_watermark" (
+  "id" boolean NOT NULL DEFAULT true,
+  "stored_until" bigint NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "chk_click_rollups_watermark_id" CHECK ("id")
+);
+-- recompute the rollups from the existing clicks, keeping the larger count
+-- for buckets whose clicks have been partly deleted
+INSERT INTO "public"."click_rollups_hourly" ("url_id", "bucket", "clicks", "unique_ips")
+SELECT "url_id", ("clicked_at" / 3600) * 3600, count(*), count(DISTINCT "ip")
+FROM "public"."clicks"
+GROUP BY 1, 2
+ON CONFLICT ("url_id", "bucket") DO UPDATE SET
+  "clicks" = greatest("click_rollups_hourly"."clicks", EXCLUDED."clicks"),
+  "unique_ips" = greatest("click_rollups_hourly"."unique_ips", EXCLUDED."unique_ips");
+INSERT INTO "public"."click_rollups_daily" ("url_id", "bucket", "clicks", "unique_ips")
+SELECT "url_id", ("clicked_at" / 86400) * 86400, count(*), count(DISTINCT "ip")
+FROM "public"."clicks"
+GROUP BY 1, 2
+ON CONFLICT ("url_id", "bucket") DO UPDATE SET
+  "clicks" = greatest("click_rollups_daily"."clicks", EXCLUDED."clicks"),
+  "unique_ips" = greatest("click_rollups_daily"."unique_ips", EXCLUDED."unique_ips");
+INSERT INTO "public"."click_rollups_breakdown" ("url_id", "bucket", "dimension", "value", "clicks")
+SELECT "url_id", ("clicked_at" / 86400) * 86400, d."dimension", d."value", count(*)
+FROM "public"."clicks"
+CROSS JOIN LATERAL (
+  VALUES
+    ('referrer', "referrer_domain"),
+    ('browser', "browser"),
+    ('os', "os"),
+    ('device', "device"),
+    ('language', "language"),
+    ('country', "country"),
+    ('region', CASE WHEN "region" = '' THEN '' ELSE "region" || ', ' || "country" END),
+    ('city', CASE WHEN "city" = '' THEN '' ELSE "city" || ', ' || "country" END),
+    ('bot', "bot" :: text)
+) AS d ("dimension", "value")
+GROUP BY 1, 2, 3, 4
+ON CONFLICT ("url_id", "bucket", "dimension", "value") DO UPDATE SET
+  "clicks" = greatest("click_rollups_breakdown"."clicks", EXCLUDED."clicks");
+-- the existing clicks have all been rolled up
+INSERT INTO "public"."click_rollups_watermark" ("stored_until")
+VALUES ((extract(epoch FROM now()) * 1000000) :: bigint + 1);
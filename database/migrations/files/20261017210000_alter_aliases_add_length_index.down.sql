@@ -0,0 +1,2 @@
+-- reverse: create index "idx_aliases_length" to table: "aliases"
+DROP INDEX "public"."idx_aliases_length";
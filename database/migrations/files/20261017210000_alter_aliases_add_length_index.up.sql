@@ -0,0 +1,2 @@
+-- create index "idx_aliases_length" to table: "aliases"
+CREATE INDEX "idx_aliases_length" ON "public"."aliases" ((length("alias")));
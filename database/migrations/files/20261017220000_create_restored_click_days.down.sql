@@ -0,0 +1,24 @@
+-- reverse: modify function "prune_clicks"
+CREATE OR REPLACE FUNCTION "public"."prune_clicks" ("before" bigint) RETURNS bigint LANGUAGE plpgsql AS $$
+DECLARE
+  part record;
+  dropped bigint := 0;
+BEGIN
+  FOR part IN
+    SELECT c."relname"
+    FROM "pg_catalog"."pg_inherits" i
+    JOIN "pg_catalog"."pg_class" c ON c."oid" = i."inhrelid"
+    WHERE i."inhparent" = '"public"."clicks"' :: regclass
+      AND c."relname" ~ '^clicks_p[0-9]{8}$'
+  LOOP
+    IF extract(epoch FROM to_date(substr(part."relname", 9), 'YYYYMMDD')) :: bigint + 86400 <= "before" THEN
+      EXECUTE format('DROP TABLE "public".%I', part."relname");
+      dropped := dropped + 1;
+    END IF;
+  END LOOP;
+  DELETE FROM "public"."clicks_default" WHERE "clicked_at" < "before";
+  RETURN dropped;
+END;
+$$;
+-- reverse: create "restored_click_days" table
+DROP TABLE "public"."restored_click_days";
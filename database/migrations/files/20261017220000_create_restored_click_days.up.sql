@@ -0,0 +1,37 @@
+-- create "restored_click_days" table
+CREATE TABLE "public"."restored_click_days" (
+  "day" bigint NOT NULL,
+  "keep_until" bigint NOT NULL,
+  PRIMARY KEY ("day")
+);
+-- modify function "prune_clicks", days restored from the archive are kept
+-- until their marker expires, which is removed once it has
+CREATE OR REPLACE FUNCTION "public"."prune_clicks" ("before" bigint) RETURNS bigint LANGUAGE plpgsql AS $$
+DECLARE
+  part record;
+  start bigint;
+  dropped bigint := 0;
+BEGIN
+  DELETE FROM "public"."restored_click_days" WHERE "keep_until" <= extract(epoch FROM now()) :: bigint;
+  FOR part IN
+    SELECT c."relname"
+    FROM "pg_catalog"."pg_inherits" i
+    JOIN "pg_catalog"."pg_class" c ON c."oid" = i."inhrelid"
+    WHERE i."inhparent" = '"public"."clicks"' :: regclass
+      AND c."relname" ~ '^clicks_p[0-9]{8}$'
+  LOOP
+    start := extract(epoch FROM to_date(substr(part."relname", 9), 'YYYYMMDD')) :: bigint;
+    IF start + 86400 <= "before"
+      AND NOT EXISTS (SELECT 1 FROM "public"."restored_click_days" r WHERE r."day" = start) THEN
+      EXECUTE format('DROP TABLE "public".%I', part."relname");
+      dropped := dropped + 1;
+    END IF;
+  END LOOP;
+  DELETE FROM "public"."clicks_default" d
+  WHERE d."clicked_at" < "before"
+    AND NOT EXISTS (
+      SELECT 1 FROM "public"."restored_click_days" r WHERE r."day" = (d."clicked_at" / 86400) * 86400
+    );
+  RETURN dropped;
+END;
+$$;
@@ -16,6 +16,25 @@ LIMIT
 UPDATE
 ;
 
+-- name: ReserveFreeAliases :many
+UPDATE
+    aliases
+SET
+    used = true
+WHERE
+    alias IN (
+        SELECT
+            alias
+        FROM
+            aliases
+        WHERE
+            used = false
+        LIMIT
+            sqlc.arg(count) FOR
+        UPDATE
+            SKIP LOCKED
+    ) RETURNING alias;
+
 -- name: MarkAliasUsed :exec
 UPDATE
     aliases
@@ -38,6 +57,14 @@ SELECT
 FROM
     aliases;
 
+-- name: CountAliasesWithLength :one
+SELECT
+    count(*)
+FROM
+    aliases
+WHERE
+    length(alias) = sqlc.arg(length);
+
 -- name: GetAliases :many
 SELECT
     alias
@@ -45,3 +72,28 @@ FROM
     aliases
 WHERE
     alias = ANY(sqlc.Slice(aliases) :: text []);
+
+-- name: ClaimAlias :one
+INSERT INTO
+    aliases (alias, used)
+VALUES
+    ($1, true) ON CONFLICT (alias) DO
+UPDATE
+SET
+    used = true
+WHERE
+    aliases.used = false RETURNING *;
+
+-- name: ReleaseAlias :exec
+DELETE FROM
+    aliases
+WHERE
+    alias = sqlc.arg(alias)
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            urls
+        WHERE
+            urls.alias = sqlc.arg(alias)
+    );
@@ -11,6 +11,25 @@ import (
 	"github.com/lib/pq"
 )
 
+const claimAlias = `-- name: ClaimAlias :one
+INSERT INTO
+    aliases (alias, used)
+VALUES
+    ($1, true) ON CONFLICT (alias) DO
+UPDATE
+SET
+    used = true
+WHERE
+    aliases.used = false RETURNING alias, used
+`
+
+func (q *Queries) ClaimAlias(ctx context.Context, alias string) (*Alias, error) {
+	row := q.db.QueryRowContext(ctx, claimAlias, alias)
+	var i Alias
+	err := row.Scan(&i.Alias, &i.Used)
+	return &i, err
+}
+
 const countAliases = `-- name: CountAliases :one
 SELECT
     count(*)
@@ -25,6 +44,22 @@ func (q *Queries) CountAliases(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const countAliasesWithLength = `-- name: CountAliasesWithLength :one
+SELECT
+    count(*)
+FROM
+    aliases
+WHERE
+    length(alias) = $1
+`
+
+func (q *Queries) CountAliasesWithLength(ctx context.Context, length int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAliasesWithLength, length)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countFreeAliases = `-- name: CountFreeAliases :one
 SELECT
     count(*)
@@ -119,3 +154,66 @@ func (q *Queries) MarkAliasUsed(ctx context.Context, alias string) error {
 	_, err := q.db.ExecContext(ctx, markAliasUsed, alias)
 	return err
 }
+
+const releaseAlias = `-- name: ReleaseAlias :exec
+DELETE FROM
+    aliases
+WHERE
+    alias = $1
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            urls
+        WHERE
+            urls.alias = $1
+    )
+`
+
+func (q *Queries) ReleaseAlias(ctx context.Context, alias string) error {
+	_, err := q.db.ExecContext(ctx, releaseAlias, alias)
+	return err
+}
+
+const reserveFreeAliases = `-- name: ReserveFreeAliases :many
+UPDATE
+    aliases
+SET
+    used = true
+WHERE
+    alias IN (
+        SELECT
+            alias
+        FROM
+            aliases
+        WHERE
+            used = false
+        LIMIT
+            $1 FOR
+        UPDATE
+            SKIP LOCKED
+    ) RETURNING alias
+`
+
+func (q *Queries) ReserveFreeAliases(ctx context.Context, count int32) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, reserveFreeAliases, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		items = append(items, alias)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -1,25 +1,184 @@
 -- name: StoreClick :exec
 INSERT INTO
-    clicks (id, url_id, ip, clicked_at)
+    clicks (
+        id,
+        url_id,
+        ip,
+        clicked_at,
+        referrer,
+        referrer_domain,
+        user_agent,
+        language,
+        browser,
+        os,
+        device,
+        bot,
+        country,
+        region,
+        city
+    )
 VALUES
-    ($1, $2, $3, $4);
+    (
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9,
+        $10,
+        $11,
+        $12,
+        $13,
+        $14,
+        $15
+    ) ON CONFLICT (id, clicked_at) DO NOTHING;
 
--- name: CountClicks :one
+-- name: StoreClicks :one
+WITH inserted AS (
+    INSERT INTO
+        clicks (
+            id,
+            url_id,
+            ip,
+            clicked_at,
+            referrer,
+            referrer_domain,
+            user_agent,
+            language,
+            browser,
+            os,
+            device,
+            bot,
+            country,
+            region,
+            city,
+            stored_at
+        )
+    SELECT
+        c.*,
+        -- Clicks restored from the archive were rolled up before they
+        -- were archived
+        CASE
+            WHEN sqlc.arg(rolled_up) :: boolean THEN 0
+            ELSE (extract(epoch FROM now()) * 1000000) :: bigint
+        END
+    FROM
+        unnest(
+            sqlc.arg(ids) :: uuid [],
+            sqlc.arg(url_ids) :: uuid [],
+            sqlc.arg(ips) :: text [],
+            sqlc.arg(clicked_ats) :: bigint [],
+            sqlc.arg(referrers) :: text [],
+            sqlc.arg(referrer_domains) :: text [],
+            sqlc.arg(user_agents) :: text [],
+            sqlc.arg(languages) :: text [],
+            sqlc.arg(browsers) :: text [],
+            sqlc.arg(oses) :: text [],
+            sqlc.arg(devices) :: text [],
+            sqlc.arg(bots) :: boolean [],
+            sqlc.arg(countries) :: text [],
+            sqlc.arg(regions) :: text [],
+            sqlc.arg(cities) :: text []
+        ) AS c (
+            id,
+            url_id,
+            ip,
+            clicked_at,
+            referrer,
+            referrer_domain,
+            user_agent,
+            language,
+            browser,
+            os,
+            device,
+            bot,
+            country,
+            region,
+            city
+        )
+    WHERE
+        -- Skip clicks for urls that have since been deleted
+        c.url_id IN (
+            SELECT
+                id
+            FROM
+                urls
+        ) ON CONFLICT (id, clicked_at) DO NOTHING RETURNING id
+)
 SELECT
     count(*)
+FROM
+    inserted;
+
+-- name: GetClickSeries :many
+SELECT
+    extract(
+        epoch
+        FROM
+            date_trunc(sqlc.arg(unit) :: text, to_timestamp(clicked_at), 'UTC')
+    ) :: bigint AS bucket,
+    count(*) AS clicks,
+    count(DISTINCT ip) AS unique_ips
 FROM
     clicks
 WHERE
-    url_id = $1;
+    url_id = sqlc.arg(url_id)
+    AND clicked_at >= sqlc.arg(since)
+    AND clicked_at < sqlc.arg(until)
+GROUP BY
+    bucket
+ORDER BY
+    bucket ASC;
 
--- name: DeleteClicks :one
-WITH deleted AS (
-    DELETE FROM
-        clicks
-    WHERE
-        clicked_at <= $1 RETURNING *
-)
+-- name: GetOldestClickTime :one
 SELECT
-    count(*)
+    clicked_at
+FROM
+    clicks
+ORDER BY
+    clicked_at ASC
+LIMIT
+    1;
+
+-- name: ListClicksBetween :many
+SELECT
+    *
 FROM
-    deleted;
+    clicks
+WHERE
+    clicked_at >= sqlc.arg(since)
+    AND clicked_at < sqlc.arg(until)
+    AND id > sqlc.arg(after)
+ORDER BY
+    id ASC
+LIMIT
+    sqlc.arg(count);
+
+-- name: CreateClicksPartition :exec
+SELECT
+    create_clicks_partition(sqlc.arg(day));
+
+-- name: PruneClicks :one
+SELECT
+    prune_clicks(sqlc.arg(before)) :: bigint AS dropped;
+
+-- name: KeepRestoredClickDays :exec
+INSERT INTO
+    restored_click_days (day, keep_until)
+SELECT
+    unnest(sqlc.arg(days) :: bigint []),
+    sqlc.arg(keep_until) :: bigint
+ON CONFLICT (day) DO UPDATE
+SET
+    keep_until = greatest(restored_click_days.keep_until, excluded.keep_until);
+
+-- name: ListRestoredClickDays :many
+SELECT
+    day
+FROM
+    restored_click_days
+WHERE
+    keep_until > sqlc.arg(now);
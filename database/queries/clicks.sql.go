@@ -9,56 +9,286 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const countClicks = `-- name: CountClicks :one
+const createClicksPartition = `-- name: CreateClicksPartition :exec
 SELECT
-    count(*)
+    create_clicks_partition($1)
+`
+
+func (q *Queries) CreateClicksPartition(ctx context.Context, day int64) error {
+	_, err := q.db.ExecContext(ctx, createClicksPartition, day)
+	return err
+}
+
+const getClickSeries = `-- name: GetClickSeries :many
+SELECT
+    extract(
+        epoch
+        FROM
+            date_trunc($1 :: text, to_timestamp(clicked_at), 'UTC')
+    ) :: bigint AS bucket,
+    count(*) AS clicks,
+    count(DISTINCT ip) AS unique_ips
 FROM
     clicks
 WHERE
-    url_id = $1
+    url_id = $2
+    AND clicked_at >= $3
+    AND clicked_at < $4
+GROUP BY
+    bucket
+ORDER BY
+    bucket ASC
 `
 
-func (q *Queries) CountClicks(ctx context.Context, urlID uuid.UUID) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countClicks, urlID)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+type GetClickSeriesParams struct {
+	Unit  string
+	UrlID uuid.UUID
+	Since int64
+	Until int64
 }
 
-const deleteClicks = `-- name: DeleteClicks :one
-WITH deleted AS (
-    DELETE FROM
-        clicks
-    WHERE
-        clicked_at <= $1 RETURNING id, url_id, ip, clicked_at
-)
+type GetClickSeriesRow struct {
+	Bucket    int64
+	Clicks    int64
+	UniqueIps int64
+}
+
+func (q *Queries) GetClickSeries(ctx context.Context, arg GetClickSeriesParams) ([]*GetClickSeriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getClickSeries,
+		arg.Unit,
+		arg.UrlID,
+		arg.Since,
+		arg.Until,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetClickSeriesRow
+	for rows.Next() {
+		var i GetClickSeriesRow
+		if err := rows.Scan(&i.Bucket, &i.Clicks, &i.UniqueIps); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOldestClickTime = `-- name: GetOldestClickTime :one
 SELECT
-    count(*)
+    clicked_at
 FROM
-    deleted
+    clicks
+ORDER BY
+    clicked_at ASC
+LIMIT
+    1
 `
 
-func (q *Queries) DeleteClicks(ctx context.Context, clickedAt int64) (int64, error) {
-	row := q.db.QueryRowContext(ctx, deleteClicks, clickedAt)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+func (q *Queries) GetOldestClickTime(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getOldestClickTime)
+	var clicked_at int64
+	err := row.Scan(&clicked_at)
+	return clicked_at, err
+}
+
+const keepRestoredClickDays = `-- name: KeepRestoredClickDays :exec
+INSERT INTO
+    restored_click_days (day, keep_until)
+SELECT
+    unnest($1 :: bigint []),
+    $2 :: bigint
+ON CONFLICT (day) DO UPDATE
+SET
+    keep_until = greatest(restored_click_days.keep_until, excluded.keep_until)
+`
+
+type KeepRestoredClickDaysParams struct {
+	Days      []int64
+	KeepUntil int64
+}
+
+func (q *Queries) KeepRestoredClickDays(ctx context.Context, arg KeepRestoredClickDaysParams) error {
+	_, err := q.db.ExecContext(ctx, keepRestoredClickDays, pq.Array(arg.Days), arg.KeepUntil)
+	return err
+}
+
+const listClicksBetween = `-- name: ListClicksBetween :many
+SELECT
+    id, url_id, ip, clicked_at, referrer, referrer_domain, user_agent, language, browser, os, device, bot, country, region, city, stored_at
+FROM
+    clicks
+WHERE
+    clicked_at >= $1
+    AND clicked_at < $2
+    AND id > $3
+ORDER BY
+    id ASC
+LIMIT
+    $4
+`
+
+type ListClicksBetweenParams struct {
+	Since int64
+	Until int64
+	After uuid.UUID
+	Count int32
+}
+
+func (q *Queries) ListClicksBetween(ctx context.Context, arg ListClicksBetweenParams) ([]*Click, error) {
+	rows, err := q.db.QueryContext(ctx, listClicksBetween,
+		arg.Since,
+		arg.Until,
+		arg.After,
+		arg.Count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Click
+	for rows.Next() {
+		var i Click
+		if err := rows.Scan(
+			&i.ID,
+			&i.UrlID,
+			&i.Ip,
+			&i.ClickedAt,
+			&i.Referrer,
+			&i.ReferrerDomain,
+			&i.UserAgent,
+			&i.Language,
+			&i.Browser,
+			&i.Os,
+			&i.Device,
+			&i.Bot,
+			&i.Country,
+			&i.Region,
+			&i.City,
+			&i.StoredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRestoredClickDays = `-- name: ListRestoredClickDays :many
+SELECT
+    day
+FROM
+    restored_click_days
+WHERE
+    keep_until > $1
+`
+
+func (q *Queries) ListRestoredClickDays(ctx context.Context, now int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listRestoredClickDays, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var day int64
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		items = append(items, day)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pruneClicks = `-- name: PruneClicks :one
+SELECT
+    prune_clicks($1) :: bigint AS dropped
+`
+
+func (q *Queries) PruneClicks(ctx context.Context, before int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, pruneClicks, before)
+	var dropped int64
+	err := row.Scan(&dropped)
+	return dropped, err
 }
 
 const storeClick = `-- name: StoreClick :exec
 INSERT INTO
-    clicks (id, url_id, ip, clicked_at)
+    clicks (
+        id,
+        url_id,
+        ip,
+        clicked_at,
+        referrer,
+        referrer_domain,
+        user_agent,
+        language,
+        browser,
+        os,
+        device,
+        bot,
+        country,
+        region,
+        city
+    )
 VALUES
-    ($1, $2, $3, $4)
+    (
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9,
+        $10,
+        $11,
+        $12,
+        $13,
+        $14,
+        $15
+    ) ON CONFLICT (id, clicked_at) DO NOTHING
 `
 
 type StoreClickParams struct {
-	ID        uuid.UUID
-	UrlID     uuid.UUID
-	Ip        string
-	ClickedAt int64
+	ID             uuid.UUID
+	UrlID          uuid.UUID
+	Ip             string
+	ClickedAt      int64
+	Referrer       string
+	ReferrerDomain string
+	UserAgent      string
+	Language       string
+	Browser        string
+	Os             string
+	Device         string
+	Bot            bool
+	Country        string
+	Region         string
+	City           string
 }
 
 func (q *Queries) StoreClick(ctx context.Context, arg StoreClickParams) error {
@@ -67,6 +297,138 @@ func (q *Queries) StoreClick(ctx context.Context, arg StoreClickParams) error {
 		arg.UrlID,
 		arg.Ip,
 		arg.ClickedAt,
+		arg.Referrer,
+		arg.ReferrerDomain,
+		arg.UserAgent,
+		arg.Language,
+		arg.Browser,
+		arg.Os,
+		arg.Device,
+		arg.Bot,
+		arg.Country,
+		arg.Region,
+		arg.City,
 	)
 	return err
 }
+
+const storeClicks = `-- name: StoreClicks :one
+WITH inserted AS (
+    INSERT INTO
+        clicks (
+            id,
+            url_id,
+            ip,
+            clicked_at,
+            referrer,
+            referrer_domain,
+            user_agent,
+            language,
+            browser,
+            os,
+            device,
+            bot,
+            country,
+            region,
+            city,
+            stored_at
+        )
+    SELECT
+        c.*,
+        -- Clicks restored from the archive were rolled up before they
+        -- were archived
+        CASE
+            WHEN $1 :: boolean THEN 0
+            ELSE (extract(epoch FROM now()) * 1000000) :: bigint
+        END
+    FROM
+        unnest(
+            $2 :: uuid [],
+            $3 :: uuid [],
+            $4 :: text [],
+            $5 :: bigint [],
+            $6 :: text [],
+            $7 :: text [],
+            $8 :: text [],
+            $9 :: text [],
+            $10 :: text [],
+            $11 :: text [],
+            $12 :: text [],
+            $13 :: boolean [],
+            $14 :: text [],
+            $15 :: text [],
+            $16 :: text []
+        ) AS c (
+            id,
+            url_id,
+            ip,
+            clicked_at,
+            referrer,
+            referrer_domain,
+            user_agent,
+            language,
+            browser,
+            os,
+            device,
+            bot,
+            country,
+            region,
+            city
+        )
+    WHERE
+        -- Skip clicks for urls that have since been deleted
+        c.url_id IN (
+            SELECT
+                id
+            FROM
+                urls
+        ) ON CONFLICT (id, clicked_at) DO NOTHING RETURNING id
+)
+SELECT
+    count(*)
+FROM
+    inserted
+`
+
+type StoreClicksParams struct {
+	Ids             []uuid.UUID
+	UrlIds          []uuid.UUID
+	Ips             []string
+	ClickedAts      []int64
+	Referrers       []string
+	ReferrerDomains []string
+	UserAgents      []string
+	Languages       []string
+	Browsers        []string
+	Oses            []string
+	Devices         []string
+	Bots            []bool
+	Countries       []string
+	Regions         []string
+	Cities          []string
+	RolledUp        bool
+}
+
+func (q *Queries) StoreClicks(ctx context.Context, arg StoreClicksParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, storeClicks,
+		pq.Array(arg.Ids),
+		pq.Array(arg.UrlIds),
+		pq.Array(arg.Ips),
+		pq.Array(arg.ClickedAts),
+		pq.Array(arg.Referrers),
+		pq.Array(arg.ReferrerDomains),
+		pq.Array(arg.UserAgents),
+		pq.Array(arg.Languages),
+		pq.Array(arg.Browsers),
+		pq.Array(arg.Oses),
+		pq.Array(arg.Devices),
+		pq.Array(arg.Bots),
+		pq.Array(arg.Countries),
+		pq.Array(arg.Regions),
+		pq.Array(arg.Cities),
+		arg.RolledUp,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
@@ -0,0 +1,31 @@
+-- name: CreateApiKey :one
+INSERT INTO
+    api_keys (id, name, hash, created_at)
+VALUES
+    ($1, $2, $3, $4) RETURNING *;
+
+-- name: GetApiKeyByHash :one
+SELECT
+    *
+FROM
+    api_keys
+WHERE
+    hash = $1
+    AND revoked_at IS NULL;
+
+-- name: ListApiKeys :many
+SELECT
+    *
+FROM
+    api_keys
+ORDER BY
+    id ASC;
+
+-- name: RevokeApiKey :execrows
+UPDATE
+    api_keys
+SET
+    revoked_at = $1
+WHERE
+    id = $2
+    AND revoked_at IS NULL;
@@ -5,6 +5,8 @@
 package queries
 
 import (
+	"database/sql"
+
 	"github.com/google/uuid"
 )
 
@@ -13,16 +15,96 @@ type Alias struct {
 	Used  bool
 }
 
-type Click struct {
+type ApiKey struct {
 	ID        uuid.UUID
+	Name      string
+	Hash      string
+	CreatedAt int64
+	RevokedAt sql.NullInt64
+}
+
+type ClickRollupsBreakdown struct {
 	UrlID     uuid.UUID
-	Ip        string
-	ClickedAt int64
+	Bucket    int64
+	Dimension string
+	Value     string
+	Clicks    int64
+}
+
+type ClickRollupsDaily struct {
+	UrlID     uuid.UUID
+	Bucket    int64
+	Clicks    int64
+	UniqueIps int64
+}
+
+type ClickRollupsHourly struct {
+	UrlID     uuid.UUID
+	Bucket    int64
+	Clicks    int64
+	UniqueIps int64
+}
+
+type ClickRollupsWatermark struct {
+	ID          bool
+	StoredUntil int64
+}
+
+type Click struct {
+	ID             uuid.UUID
+	UrlID          uuid.UUID
+	Ip             string
+	ClickedAt      int64
+	Referrer       string
+	ReferrerDomain string
+	UserAgent      string
+	Language       string
+	Browser        string
+	Os             string
+	Device         string
+	Bot            bool
+	Country        string
+	Region         string
+	City           string
+	StoredAt       int64
+}
+
+type RestoredClickDay struct {
+	Day       int64
+	KeepUntil int64
 }
 
 type Url struct {
-	ID     uuid.UUID
-	Alias  string
-	Url    string
-	Domain string
+	ID               uuid.UUID
+	Alias            string
+	Url              string
+	Domain           string
+	ExpiresAt        sql.NullInt64
+	MaxClicks        sql.NullInt64
+	Visits           int64
+	OwnerID          uuid.NullUUID
+	QuarantinedAt    sql.NullInt64
+	QuarantineReason sql.NullString
+}
+
+type WebhookDelivery struct {
+	ID           uuid.UUID
+	WebhookID    uuid.UUID
+	Event        string
+	Payload      string
+	Status       string
+	Attempts     int32
+	ResponseCode sql.NullInt32
+	Error        sql.NullString
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+type Webhook struct {
+	ID        uuid.UUID
+	OwnerID   uuid.NullUUID
+	Url       string
+	Secret    string
+	Events    []string
+	CreatedAt int64
 }
@@ -0,0 +1,255 @@
+-- name: LockRollupWatermark :one
+SELECT
+    stored_until
+FROM
+    click_rollups_watermark FOR
+UPDATE;
+
+-- name: AdvanceRollupWatermark :one
+UPDATE
+    click_rollups_watermark
+SET
+    stored_until = greatest(
+        stored_until,
+        (extract(epoch FROM now()) * 1000000) :: bigint - sqlc.arg(delay) :: bigint
+    ) RETURNING stored_until;
+
+-- name: RollupHourlyClicks :exec
+INSERT INTO
+    click_rollups_hourly (url_id, bucket, clicks, unique_ips)
+SELECT
+    stored.url_id,
+    stored.bucket,
+    stored.clicks,
+    (
+        SELECT
+            count(DISTINCT ip)
+        FROM
+            clicks
+        WHERE
+            clicks.url_id = stored.url_id
+            AND clicked_at >= stored.bucket
+            AND clicked_at < stored.bucket + 3600
+    ) AS unique_ips
+FROM
+    (
+        SELECT
+            url_id,
+            (clicked_at / 3600) * 3600 AS bucket,
+            count(*) AS clicks
+        FROM
+            clicks
+        WHERE
+            stored_at >= sqlc.arg(since)
+            AND stored_at < sqlc.arg(until)
+        GROUP BY
+            url_id,
+            bucket
+    ) AS stored ON CONFLICT (url_id, bucket) DO
+UPDATE
+SET
+    clicks = click_rollups_hourly.clicks + EXCLUDED.clicks,
+    -- Kept when the raw clicks for the bucket have been deleted
+    unique_ips = greatest(click_rollups_hourly.unique_ips, EXCLUDED.unique_ips);
+
+-- name: RollupDailyClicks :exec
+INSERT INTO
+    click_rollups_daily (url_id, bucket, clicks, unique_ips)
+SELECT
+    stored.url_id,
+    stored.bucket,
+    (
+        SELECT
+            coalesce(sum(clicks), 0)
+        FROM
+            click_rollups_hourly
+        WHERE
+            click_rollups_hourly.url_id = stored.url_id
+            AND bucket >= stored.bucket
+            AND bucket < stored.bucket + 86400
+    ) AS clicks,
+    (
+        SELECT
+            count(DISTINCT ip)
+        FROM
+            clicks
+        WHERE
+            clicks.url_id = stored.url_id
+            AND clicked_at >= stored.bucket
+            AND clicked_at < stored.bucket + 86400
+    ) AS unique_ips
+FROM
+    (
+        SELECT
+            DISTINCT url_id,
+            (clicked_at / 86400) * 86400 AS bucket
+        FROM
+            clicks
+        WHERE
+            stored_at >= sqlc.arg(since)
+            AND stored_at < sqlc.arg(until)
+    ) AS stored ON CONFLICT (url_id, bucket) DO
+UPDATE
+SET
+    clicks = EXCLUDED.clicks,
+    unique_ips = greatest(click_rollups_daily.unique_ips, EXCLUDED.unique_ips);
+
+-- name: RollupClickBreakdown :exec
+INSERT INTO
+    click_rollups_breakdown (url_id, bucket, dimension, value, clicks)
+SELECT
+    url_id,
+    (clicked_at / 86400) * 86400 AS bucket,
+    d.dimension,
+    d.value,
+    count(*) AS clicks
+FROM
+    clicks
+    CROSS JOIN LATERAL (
+            VALUES
+                ('referrer', referrer_domain),
+                ('browser', browser),
+                ('os', os),
+                ('device', device),
+                ('language', language),
+                ('country', country),
+                (
+                    'region',
+                    CASE
+                        WHEN region = '' THEN ''
+                        ELSE region || ', ' || country
+                    END
+                ),
+                (
+                    'city',
+                    CASE
+                        WHEN city = '' THEN ''
+                        ELSE city || ', ' || country
+                    END
+                ),
+                ('bot', bot :: text)
+    ) AS d (dimension, value)
+WHERE
+    stored_at >= sqlc.arg(since)
+    AND stored_at < sqlc.arg(until)
+GROUP BY
+    url_id,
+    bucket,
+    d.dimension,
+    d.value ON CONFLICT (url_id, bucket, dimension, value) DO
+UPDATE
+SET
+    clicks = click_rollups_breakdown.clicks + EXCLUDED.clicks;
+
+-- name: CountClicks :one
+SELECT
+    (
+        (
+            SELECT
+                coalesce(sum(clicks), 0)
+            FROM
+                click_rollups_daily
+            WHERE
+                click_rollups_daily.url_id = $1
+        ) + (
+            SELECT
+                count(*)
+            FROM
+                clicks
+            WHERE
+                clicks.url_id = $1
+                AND stored_at >= (
+                    SELECT
+                        stored_until
+                    FROM
+                        click_rollups_watermark
+                )
+        )
+    ) :: bigint AS clicks;
+
+-- name: GetClickBreakdown :many
+SELECT
+    dimension,
+    value,
+    sum(clicks) :: bigint AS clicks
+FROM
+    (
+        SELECT
+            dimension,
+            value,
+            clicks
+        FROM
+            click_rollups_breakdown
+        WHERE
+            click_rollups_breakdown.url_id = $1
+        UNION ALL
+        SELECT
+            d.dimension,
+            d.value,
+            1 AS clicks
+        FROM
+            clicks
+            CROSS JOIN LATERAL (
+                    VALUES
+                        ('referrer', referrer_domain),
+                        ('browser', browser),
+                        ('os', os),
+                        ('device', device),
+                        ('language', language),
+                        ('country', country),
+                        (
+                            'region',
+                            CASE
+                                WHEN region = '' THEN ''
+                                ELSE region || ', ' || country
+                            END
+                        ),
+                        (
+                            'city',
+                            CASE
+                                WHEN city = '' THEN ''
+                                ELSE city || ', ' || country
+                            END
+                        ),
+                        ('bot', bot :: text)
+            ) AS d (dimension, value)
+        WHERE
+            clicks.url_id = $1
+            AND stored_at >= (
+                SELECT
+                    stored_until
+                FROM
+                    click_rollups_watermark
+            )
+    ) AS breakdown
+GROUP BY
+    dimension,
+    value;
+
+-- name: GetHourlyClickSeries :many
+SELECT
+    bucket,
+    clicks,
+    unique_ips
+FROM
+    click_rollups_hourly
+WHERE
+    url_id = sqlc.arg(url_id)
+    AND bucket >= sqlc.arg(since)
+    AND bucket < sqlc.arg(until)
+ORDER BY
+    bucket ASC;
+
+-- name: GetDailyClickSeries :many
+SELECT
+    bucket,
+    clicks,
+    unique_ips
+FROM
+    click_rollups_daily
+WHERE
+    url_id = sqlc.arg(url_id)
+    AND bucket >= sqlc.arg(since)
+    AND bucket < sqlc.arg(until)
+ORDER BY
+    bucket ASC;
@@ -1,8 +1,8 @@
 -- name: CreateUrl :one
 INSERT INTO
-    urls (id, alias, url, domain)
+    urls (id, alias, url, domain, expires_at, max_clicks, owner_id)
 VALUES
-    ($1, $2, $3, $4) RETURNING *;
+    ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
 
 -- name: GetUrl :one
 SELECT
@@ -20,8 +20,152 @@ FROM
 WHERE
     alias = $1;
 
+-- name: GetUrlByDestination :one
+SELECT
+    *
+FROM
+    urls
+WHERE
+    url = sqlc.arg(url)
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg(owner_id)
+    AND expires_at IS NULL
+    AND max_clicks IS NULL
+    AND quarantined_at IS NULL
+ORDER BY
+    id ASC
+LIMIT
+    1;
+
+-- name: QuarantineUrl :one
+UPDATE
+    urls
+SET
+    quarantined_at = $1,
+    quarantine_reason = $2
+WHERE
+    id = $3
+    AND quarantined_at IS NULL RETURNING *;
+
+-- name: UnquarantineUrl :one
+UPDATE
+    urls
+SET
+    quarantined_at = NULL,
+    quarantine_reason = NULL
+WHERE
+    id = $1
+    AND quarantined_at IS NOT NULL RETURNING *;
+
 -- name: CountUrls :one
 SELECT
     count(*)
 FROM
     urls;
+
+-- name: IncrementUrlVisits :one
+UPDATE
+    urls
+SET
+    visits = visits + 1,
+    expires_at = CASE
+        WHEN visits + 1 >= max_clicks THEN LEAST(
+            COALESCE(expires_at, sqlc.arg(now) :: bigint),
+            sqlc.arg(now) :: bigint
+        )
+        ELSE expires_at
+    END
+WHERE
+    id = sqlc.arg(id)
+    AND visits < max_clicks RETURNING *;
+
+-- name: DeleteExpiredUrls :many
+DELETE FROM
+    urls
+WHERE
+    expires_at <= $1 RETURNING *;
+
+-- name: UpdateUrl :one
+UPDATE
+    urls
+SET
+    url = $1,
+    quarantined_at = NULL,
+    quarantine_reason = NULL
+WHERE
+    id = $2 RETURNING *;
+
+-- name: DeleteUrl :one
+DELETE FROM
+    urls
+WHERE
+    id = $1 RETURNING *;
+
+-- name: ListUrls :many
+SELECT
+    *
+FROM
+    urls
+WHERE
+    (
+        sqlc.narg(cursor) :: uuid IS NULL
+        OR id > sqlc.narg(cursor)
+    )
+    AND (
+        sqlc.narg(domain) :: text IS NULL
+        OR domain = sqlc.narg(domain)
+    )
+    AND (
+        sqlc.narg(search) :: text IS NULL
+        OR url ILIKE '%' || sqlc.narg(search) || '%'
+    )
+    AND (
+        sqlc.narg(created_after) :: uuid IS NULL
+        OR id >= sqlc.narg(created_after)
+    )
+    AND (
+        sqlc.narg(created_before) :: uuid IS NULL
+        OR id < sqlc.narg(created_before)
+    )
+    AND (
+        sqlc.narg(owner_id) :: uuid IS NULL
+        OR owner_id = sqlc.narg(owner_id)
+    )
+ORDER BY
+    id ASC
+LIMIT
+    sqlc.arg(page_size);
+
+-- name: ListUrlsDesc :many
+SELECT
+    *
+FROM
+    urls
+WHERE
+    (
+        sqlc.narg(cursor) :: uuid IS NULL
+        OR id < sqlc.narg(cursor)
+    )
+    AND (
+        sqlc.narg(domain) :: text IS NULL
+        OR domain = sqlc.narg(domain)
+    )
+    AND (
+        sqlc.narg(search) :: text IS NULL
+        OR url ILIKE '%' || sqlc.narg(search) || '%'
+    )
+    AND (
+        sqlc.narg(created_after) :: uuid IS NULL
+        OR id >= sqlc.narg(created_after)
+    )
+    AND (
+        sqlc.narg(created_before) :: uuid IS NULL
+        OR id < sqlc.narg(created_before)
+    )
+    AND (
+        sqlc.narg(owner_id) :: uuid IS NULL
+        OR owner_id = sqlc.narg(owner_id)
+    )
+ORDER BY
+    id DESC
+LIMIT
+    sqlc.arg(page_size);
@@ -7,6 +7,7 @@ package queries
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
@@ -27,16 +28,19 @@ func (q *Queries) CountUrls(ctx context.Context) (int64, error) {
 
 const createUrl = `-- name: CreateUrl :one
 INSERT INTO
-    urls (id, alias, url, domain)
+    urls (id, alias, url, domain, expires_at, max_clicks, owner_id)
 VALUES
-    ($1, $2, $3, $4) RETURNING id, alias, url, domain
+    ($1, $2, $3, $4, $5, $6, $7) RETURNING id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
 `
 
 type CreateUrlParams struct {
-	ID     uuid.UUID
-	Alias  string
-	Url    string
-	Domain string
+	ID        uuid.UUID
+	Alias     string
+	Url       string
+	Domain    string
+	ExpiresAt sql.NullInt64
+	MaxClicks sql.NullInt64
+	OwnerID   uuid.NullUUID
 }
 
 func (q *Queries) CreateUrl(ctx context.Context, arg CreateUrlParams) (*Url, error) {
@@ -45,6 +49,9 @@ func (q *Queries) CreateUrl(ctx context.Context, arg CreateUrlParams) (*Url, err
 		arg.Alias,
 		arg.Url,
 		arg.Domain,
+		arg.ExpiresAt,
+		arg.MaxClicks,
+		arg.OwnerID,
 	)
 	var i Url
 	err := row.Scan(
@@ -52,13 +59,85 @@ func (q *Queries) CreateUrl(ctx context.Context, arg CreateUrlParams) (*Url, err
 		&i.Alias,
 		&i.Url,
 		&i.Domain,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.Visits,
+		&i.OwnerID,
+		&i.QuarantinedAt,
+		&i.QuarantineReason,
+	)
+	return &i, err
+}
+
+const deleteExpiredUrls = `-- name: DeleteExpiredUrls :many
+DELETE FROM
+    urls
+WHERE
+    expires_at <= $1 RETURNING id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
+`
+
+func (q *Queries) DeleteExpiredUrls(ctx context.Context, expiresAt sql.NullInt64) ([]*Url, error) {
+	rows, err := q.db.QueryContext(ctx, deleteExpiredUrls, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Url
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.Alias,
+			&i.Url,
+			&i.Domain,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.Visits,
+			&i.OwnerID,
+			&i.QuarantinedAt,
+			&i.QuarantineReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteUrl = `-- name: DeleteUrl :one
+DELETE FROM
+    urls
+WHERE
+    id = $1 RETURNING id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
+`
+
+func (q *Queries) DeleteUrl(ctx context.Context, id uuid.UUID) (*Url, error) {
+	row := q.db.QueryRowContext(ctx, deleteUrl, id)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.Alias,
+		&i.Url,
+		&i.Domain,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.Visits,
+		&i.OwnerID,
+		&i.QuarantinedAt,
+		&i.QuarantineReason,
 	)
 	return &i, err
 }
 
 const getUrl = `-- name: GetUrl :one
 SELECT
-    id, alias, url, domain
+    id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
 FROM
     urls
 WHERE
@@ -73,13 +152,19 @@ func (q *Queries) GetUrl(ctx context.Context, id uuid.UUID) (*Url, error) {
 		&i.Alias,
 		&i.Url,
 		&i.Domain,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.Visits,
+		&i.OwnerID,
+		&i.QuarantinedAt,
+		&i.QuarantineReason,
 	)
 	return &i, err
 }
 
 const getUrlByAlias = `-- name: GetUrlByAlias :one
 SELECT
-    id, alias, url, domain
+    id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
 FROM
     urls
 WHERE
@@ -94,6 +179,366 @@ func (q *Queries) GetUrlByAlias(ctx context.Context, alias string) (*Url, error)
 		&i.Alias,
 		&i.Url,
 		&i.Domain,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.Visits,
+		&i.OwnerID,
+		&i.QuarantinedAt,
+		&i.QuarantineReason,
+	)
+	return &i, err
+}
+
+const getUrlByDestination = `-- name: GetUrlByDestination :one
+SELECT
+    id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
+FROM
+    urls
+WHERE
+    url = $1
+    AND owner_id IS NOT DISTINCT FROM $2
+    AND expires_at IS NULL
+    AND max_clicks IS NULL
+    AND quarantined_at IS NULL
+ORDER BY
+    id ASC
+LIMIT
+    1
+`
+
+type GetUrlByDestinationParams struct {
+	Url     string
+	OwnerID uuid.NullUUID
+}
+
+func (q *Queries) GetUrlByDestination(ctx context.Context, arg GetUrlByDestinationParams) (*Url, error) {
+	row := q.db.QueryRowContext(ctx, getUrlByDestination, arg.Url, arg.OwnerID)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.Alias,
+		&i.Url,
+		&i.Domain,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.Visits,
+		&i.OwnerID,
+		&i.QuarantinedAt,
+		&i.QuarantineReason,
+	)
+	return &i, err
+}
+
+const incrementUrlVisits = `-- name: IncrementUrlVisits :one
+UPDATE
+    urls
+SET
+    visits = visits + 1,
+    expires_at = CASE
+        WHEN visits + 1 >= max_clicks THEN LEAST(
+            COALESCE(expires_at, $1 :: bigint),
+            $1 :: bigint
+        )
+        ELSE expires_at
+    END
+WHERE
+    id = $2
+    AND visits < max_clicks RETURNING id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
+`
+
+type IncrementUrlVisitsParams struct {
+	Now int64
+	ID  uuid.UUID
+}
+
+func (q *Queries) IncrementUrlVisits(ctx context.Context, arg IncrementUrlVisitsParams) (*Url, error) {
+	row := q.db.QueryRowContext(ctx, incrementUrlVisits, arg.Now, arg.ID)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.Alias,
+		&i.Url,
+		&i.Domain,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.Visits,
+		&i.OwnerID,
+		&i.QuarantinedAt,
+		&i.QuarantineReason,
+	)
+	return &i, err
+}
+
+const listUrls = `-- name: ListUrls :many
+SELECT
+    id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
+FROM
+    urls
+WHERE
+    (
+        $1 :: uuid IS NULL
+        OR id > $1
+    )
+    AND (
+        $2 :: text IS NULL
+        OR domain = $2
+    )
+    AND (
+        $3 :: text IS NULL
+        OR url ILIKE '%' || $3 || '%'
+    )
+    AND (
+        $4 :: uuid IS NULL
+        OR id >= $4
+    )
+    AND (
+        $5 :: uuid IS NULL
+        OR id < $5
+    )
+    AND (
+        $6 :: uuid IS NULL
+        OR owner_id = $6
+    )
+ORDER BY
+    id ASC
+LIMIT
+    $7
+`
+
+type ListUrlsParams struct {
+	Cursor        uuid.NullUUID
+	Domain        sql.NullString
+	Search        sql.NullString
+	CreatedAfter  uuid.NullUUID
+	CreatedBefore uuid.NullUUID
+	OwnerID       uuid.NullUUID
+	PageSize      int32
+}
+
+func (q *Queries) ListUrls(ctx context.Context, arg ListUrlsParams) ([]*Url, error) {
+	rows, err := q.db.QueryContext(ctx, listUrls,
+		arg.Cursor,
+		arg.Domain,
+		arg.Search,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.OwnerID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Url
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.Alias,
+			&i.Url,
+			&i.Domain,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.Visits,
+			&i.OwnerID,
+			&i.QuarantinedAt,
+			&i.QuarantineReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUrlsDesc = `-- name: ListUrlsDesc :many
+SELECT
+    id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
+FROM
+    urls
+WHERE
+    (
+        $1 :: uuid IS NULL
+        OR id < $1
+    )
+    AND (
+        $2 :: text IS NULL
+        OR domain = $2
+    )
+    AND (
+        $3 :: text IS NULL
+        OR url ILIKE '%' || $3 || '%'
+    )
+    AND (
+        $4 :: uuid IS NULL
+        OR id >= $4
+    )
+    AND (
+        $5 :: uuid IS NULL
+        OR id < $5
+    )
+    AND (
+        $6 :: uuid IS NULL
+        OR owner_id = $6
+    )
+ORDER BY
+    id DESC
+LIMIT
+    $7
+`
+
+type ListUrlsDescParams struct {
+	Cursor        uuid.NullUUID
+	Domain        sql.NullString
+	Search        sql.NullString
+	CreatedAfter  uuid.NullUUID
+	CreatedBefore uuid.NullUUID
+	OwnerID       uuid.NullUUID
+	PageSize      int32
+}
+
+func (q *Queries) ListUrlsDesc(ctx context.Context, arg ListUrlsDescParams) ([]*Url, error) {
+	rows, err := q.db.QueryContext(ctx, listUrlsDesc,
+		arg.Cursor,
+		arg.Domain,
+		arg.Search,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.OwnerID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Url
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.Alias,
+			&i.Url,
+			&i.Domain,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.Visits,
+			&i.OwnerID,
+			&i.QuarantinedAt,
+			&i.QuarantineReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const quarantineUrl = `-- name: QuarantineUrl :one
+UPDATE
+    urls
+SET
+    quarantined_at = $1,
+    quarantine_reason = $2
+WHERE
+    id = $3
+    AND quarantined_at IS NULL RETURNING id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
+`
+
+type QuarantineUrlParams struct {
+	QuarantinedAt    sql.NullInt64
+	QuarantineReason sql.NullString
+	ID               uuid.UUID
+}
+
+func (q *Queries) QuarantineUrl(ctx context.Context, arg QuarantineUrlParams) (*Url, error) {
+	row := q.db.QueryRowContext(ctx, quarantineUrl, arg.QuarantinedAt, arg.QuarantineReason, arg.ID)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.Alias,
+		&i.Url,
+		&i.Domain,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.Visits,
+		&i.OwnerID,
+		&i.QuarantinedAt,
+		&i.QuarantineReason,
+	)
+	return &i, err
+}
+
+const unquarantineUrl = `-- name: UnquarantineUrl :one
+UPDATE
+    urls
+SET
+    quarantined_at = NULL,
+    quarantine_reason = NULL
+WHERE
+    id = $1
+    AND quarantined_at IS NOT NULL RETURNING id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
+`
+
+func (q *Queries) UnquarantineUrl(ctx context.Context, id uuid.UUID) (*Url, error) {
+	row := q.db.QueryRowContext(ctx, unquarantineUrl, id)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.Alias,
+		&i.Url,
+		&i.Domain,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.Visits,
+		&i.OwnerID,
+		&i.QuarantinedAt,
+		&i.QuarantineReason,
+	)
+	return &i, err
+}
+
+const updateUrl = `-- name: UpdateUrl :one
+UPDATE
+    urls
+SET
+    url = $1,
+    quarantined_at = NULL,
+    quarantine_reason = NULL
+WHERE
+    id = $2 RETURNING id, alias, url, domain, expires_at, max_clicks, visits, owner_id, quarantined_at, quarantine_reason
+`
+
+type UpdateUrlParams struct {
+	Url string
+	ID  uuid.UUID
+}
+
+func (q *Queries) UpdateUrl(ctx context.Context, arg UpdateUrlParams) (*Url, error) {
+	row := q.db.QueryRowContext(ctx, updateUrl, arg.Url, arg.ID)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.Alias,
+		&i.Url,
+		&i.Domain,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.Visits,
+		&i.OwnerID,
+		&i.QuarantinedAt,
+		&i.QuarantineReason,
 	)
 	return &i, err
 }
@@ -0,0 +1,94 @@
+-- name: CreateWebhook :one
+INSERT INTO
+    webhooks (id, owner_id, url, secret, events, created_at)
+VALUES
+    ($1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- name: GetWebhook :one
+SELECT
+    *
+FROM
+    webhooks
+WHERE
+    id = $1;
+
+-- name: ListWebhooks :many
+SELECT
+    *
+FROM
+    webhooks
+WHERE
+    owner_id IS NOT DISTINCT FROM sqlc.narg(owner_id)
+ORDER BY
+    id ASC;
+
+-- name: ListWebhooksForEvent :many
+SELECT
+    *
+FROM
+    webhooks
+WHERE
+    (
+        owner_id IS NULL
+        OR owner_id = sqlc.narg(owner_id)
+    )
+    AND sqlc.arg(event) :: text = ANY(events)
+ORDER BY
+    id ASC;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM
+    webhooks
+WHERE
+    id = sqlc.arg(id)
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg(owner_id);
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO
+    webhook_deliveries (
+        id,
+        webhook_id,
+        event,
+        payload,
+        status,
+        created_at,
+        updated_at
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $6) RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT
+    *
+FROM
+    webhook_deliveries
+WHERE
+    id = $1;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE
+    webhook_deliveries
+SET
+    status = sqlc.arg(status),
+    attempts = attempts + 1,
+    response_code = sqlc.narg(response_code),
+    error = sqlc.narg(error),
+    updated_at = sqlc.arg(updated_at)
+WHERE
+    id = sqlc.arg(id);
+
+-- name: ListWebhookDeliveries :many
+SELECT
+    *
+FROM
+    webhook_deliveries
+WHERE
+    webhook_id = sqlc.arg(webhook_id)
+    AND (
+        sqlc.narg(cursor) :: uuid IS NULL
+        OR id < sqlc.narg(cursor)
+    )
+ORDER BY
+    id DESC
+LIMIT
+    sqlc.arg(page_size);
@@ -23,6 +23,37 @@ table "urls" {
     null = false
   }
 
+  column "expires_at" {
+    type = bigint
+    null = true
+  }
+
+  column "max_clicks" {
+    type = bigint
+    null = true
+  }
+
+  column "visits" {
+    type    = bigint
+    null    = false
+    default = 0
+  }
+
+  column "owner_id" {
+    type = uuid
+    null = true
+  }
+
+  column "quarantined_at" {
+    type = bigint
+    null = true
+  }
+
+  column "quarantine_reason" {
+    type = text
+    null = true
+  }
+
   primary_key {
     columns = [column.id]
   }
@@ -30,11 +61,26 @@ table "urls" {
     columns = [column.alias]
     unique  = true
   }
+  index "idx_urls_expires_at" {
+    columns = [column.expires_at]
+  }
+  index "idx_urls_owner_id" {
+    columns = [column.owner_id]
+  }
+  index "idx_urls_url" {
+    columns = [column.url]
+    type    = HASH
+  }
   foreign_key "fk_urls_alias" {
     columns     = [column.alias]
     ref_columns = [table.aliases.column.alias]
     on_delete   = CASCADE
   }
+  foreign_key "fk_urls_owner_id" {
+    columns     = [column.owner_id]
+    ref_columns = [table.api_keys.column.id]
+    on_delete   = SET_NULL
+  }
 }
 
 table "aliases" {
@@ -57,6 +103,11 @@ table "aliases" {
   index "idx_aliases_used" {
     columns = [column.used]
   }
+  index "idx_aliases_length" {
+    on {
+      expr = "length(alias)"
+    }
+  }
 }
 
 table "clicks" {
@@ -82,8 +133,85 @@ table "clicks" {
     null = false
   }
 
+  column "referrer" {
+    type    = text
+    null    = false
+    default = ""
+  }
+
+  column "referrer_domain" {
+    type    = text
+    null    = false
+    default = ""
+  }
+
+  column "user_agent" {
+    type    = text
+    null    = false
+    default = ""
+  }
+
+  column "language" {
+    type    = text
+    null    = false
+    default = ""
+  }
+
+  column "browser" {
+    type    = text
+    null    = false
+    default = ""
+  }
+
+  column "os" {
+    type    = text
+    null    = false
+    default = ""
+  }
+
+  column "device" {
+    type    = text
+    null    = false
+    default = ""
+  }
+
+  column "bot" {
+    type    = boolean
+    null    = false
+    default = false
+  }
+
+  column "country" {
+    type    = text
+    null    = false
+    default = ""
+  }
+
+  column "region" {
+    type    = text
+    null    = false
+    default = ""
+  }
+
+  column "city" {
+    type    = text
+    null    = false
+    default = ""
+  }
+
+  column "stored_at" {
+    type    = bigint
+    null    = false
+    default = sql("(extract(epoch FROM now()) * 1000000) :: bigint")
+  }
+
   primary_key {
-    columns = [column.id]
+    columns = [column.id, column.clicked_at]
+  }
+
+  partition {
+    type    = RANGE
+    columns = [column.clicked_at]
   }
 
   foreign_key "fk_clicks_url_id" {
@@ -91,7 +219,316 @@ table "clicks" {
     ref_columns = [table.urls.column.id]
     on_delete   = CASCADE
   }
-  index "idx_clicks_url_id" {
-    columns = [column.url_id]
+  index "idx_clicks_url_id_clicked_at" {
+    columns = [column.url_id, column.clicked_at]
+  }
+  index "idx_clicks_clicked_at" {
+    columns = [column.clicked_at]
+  }
+  index "idx_clicks_stored_at" {
+    columns = [column.stored_at]
+  }
+  index "idx_clicks_url_id_stored_at" {
+    columns = [column.url_id, column.stored_at]
+  }
+}
+
+table "api_keys" {
+  schema = schema.public
+
+  column "id" {
+    type = uuid
+    null = false
+  }
+
+  column "name" {
+    type = text
+    null = false
+  }
+
+  column "hash" {
+    type = text
+    null = false
+  }
+
+  column "created_at" {
+    type = bigint
+    null = false
+  }
+
+  column "revoked_at" {
+    type = bigint
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  index "idx_api_keys_hash" {
+    columns = [column.hash]
+    unique  = true
+  }
+}
+
+table "click_rollups_hourly" {
+  schema = schema.public
+
+  column "url_id" {
+    type = uuid
+    null = false
+  }
+
+  column "bucket" {
+    type = bigint
+    null = false
+  }
+
+  column "clicks" {
+    type = bigint
+    null = false
+  }
+
+  column "unique_ips" {
+    type = bigint
+    null = false
+  }
+
+  primary_key {
+    columns = [column.url_id, column.bucket]
+  }
+
+  foreign_key "fk_click_rollups_hourly_url_id" {
+    columns     = [column.url_id]
+    ref_columns = [table.urls.column.id]
+    on_delete   = CASCADE
+  }
+}
+
+table "click_rollups_daily" {
+  schema = schema.public
+
+  column "url_id" {
+    type = uuid
+    null = false
+  }
+
+  column "bucket" {
+    type = bigint
+    null = false
+  }
+
+  column "clicks" {
+    type = bigint
+    null = false
+  }
+
+  column "unique_ips" {
+    type = bigint
+    null = false
+  }
+
+  primary_key {
+    columns = [column.url_id, column.bucket]
+  }
+
+  foreign_key "fk_click_rollups_daily_url_id" {
+    columns     = [column.url_id]
+    ref_columns = [table.urls.column.id]
+    on_delete   = CASCADE
+  }
+}
+
+table "click_rollups_breakdown" {
+  schema = schema.public
+
+  column "url_id" {
+    type = uuid
+    null = false
+  }
+
+  column "bucket" {
+    type = bigint
+    null = false
+  }
+
+  column "dimension" {
+    type = text
+    null = false
+  }
+
+  column "value" {
+    type = text
+    null = false
+  }
+
+  column "clicks" {
+    type = bigint
+    null = false
+  }
+
+  primary_key {
+    columns = [column.url_id, column.bucket, column.dimension, column.value]
+  }
+
+  foreign_key "fk_click_rollups_breakdown_url_id" {
+    columns     = [column.url_id]
+    ref_columns = [table.urls.column.id]
+    on_delete   = CASCADE
+  }
+}
+
+table "click_rollups_watermark" {
+  schema = schema.public
+
+  column "id" {
+    type    = boolean
+    null    = false
+    default = true
+  }
+
+  column "stored_until" {
+    type = bigint
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  check "chk_click_rollups_watermark_id" {
+    expr = "id"
+  }
+}
+
+table "restored_click_days" {
+  schema = schema.public
+
+  column "day" {
+    type = bigint
+    null = false
+  }
+
+  column "keep_until" {
+    type = bigint
+    null = false
+  }
+
+  primary_key {
+    columns = [column.day]
+  }
+}
+
+table "webhooks" {
+  schema = schema.public
+
+  column "id" {
+    type = uuid
+    null = false
+  }
+
+  column "owner_id" {
+    type = uuid
+    null = true
+  }
+
+  column "url" {
+    type = text
+    null = false
+  }
+
+  column "secret" {
+    type = text
+    null = false
+  }
+
+  column "events" {
+    type = sql("text[]")
+    null = false
+  }
+
+  column "created_at" {
+    type = bigint
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_webhooks_owner_id" {
+    columns     = [column.owner_id]
+    ref_columns = [table.api_keys.column.id]
+    on_delete   = CASCADE
+  }
+  index "idx_webhooks_owner_id" {
+    columns = [column.owner_id]
+  }
+}
+
+table "webhook_deliveries" {
+  schema = schema.public
+
+  column "id" {
+    type = uuid
+    null = false
+  }
+
+  column "webhook_id" {
+    type = uuid
+    null = false
+  }
+
+  column "event" {
+    type = text
+    null = false
+  }
+
+  column "payload" {
+    type = text
+    null = false
+  }
+
+  column "status" {
+    type = text
+    null = false
+  }
+
+  column "attempts" {
+    type    = integer
+    null    = false
+    default = 0
+  }
+
+  column "response_code" {
+    type = integer
+    null = true
+  }
+
+  column "error" {
+    type = text
+    null = true
+  }
+
+  column "created_at" {
+    type = bigint
+    null = false
+  }
+
+  column "updated_at" {
+    type = bigint
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_webhook_deliveries_webhook_id" {
+    columns     = [column.webhook_id]
+    ref_columns = [table.webhooks.column.id]
+    on_delete   = CASCADE
+  }
+  index "idx_webhook_deliveries_webhook_id" {
+    columns = [column.webhook_id, column.id]
   }
 }
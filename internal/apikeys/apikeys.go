@@ -0,0 +1,132 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/database/queries"
+	"github.com/henrywhitaker3/shorturl/internal/crypto"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+)
+
+const (
+	prefix = "sk_"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid api key")
+)
+
+type Key struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func mapKey(k *queries.ApiKey) *Key {
+	out := &Key{
+		ID:        uuid.UUID(k.ID),
+		Name:      k.Name,
+		CreatedAt: time.Unix(k.CreatedAt, 0),
+	}
+	if k.RevokedAt.Valid {
+		at := time.Unix(k.RevokedAt.Int64, 0)
+		out.RevokedAt = &at
+	}
+	return out
+}
+
+type Keys struct {
+	db *queries.Queries
+}
+
+type KeysOpts struct {
+	DB *queries.Queries
+}
+
+func New(opts KeysOpts) *Keys {
+	return &Keys{
+		db: opts.DB,
+	}
+}
+
+// Creates a new api key, returning the plaintext token. Only a hash of the
+// token is stored so it can't be retrieved again.
+func (k *Keys) Create(ctx context.Context, name string) (*Key, string, error) {
+	id, err := uuid.Ordered()
+	if err != nil {
+		return nil, "", fmt.Errorf("generate key id: %w", err)
+	}
+	token, err := generateToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("generate key token: %w", err)
+	}
+
+	key, err := k.db.CreateApiKey(ctx, queries.CreateApiKeyParams{
+		ID:        id.UUID(),
+		Name:      name,
+		Hash:      crypto.Sum(token),
+		CreatedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("store api key: %w", err)
+	}
+
+	return mapKey(key), token, nil
+}
+
+// Returns the active key for the token, or ErrInvalidKey
+func (k *Keys) Verify(ctx context.Context, token string) (*Key, error) {
+	if !strings.HasPrefix(token, prefix) {
+		return nil, ErrInvalidKey
+	}
+	key, err := k.db.GetApiKeyByHash(ctx, crypto.Sum(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidKey
+		}
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+	return mapKey(key), nil
+}
+
+func (k *Keys) List(ctx context.Context) ([]*Key, error) {
+	keys, err := k.db.ListApiKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	out := []*Key{}
+	for _, key := range keys {
+		out = append(out, mapKey(key))
+	}
+	return out, nil
+}
+
+func (k *Keys) Revoke(ctx context.Context, id uuid.UUID) error {
+	revoked, err := k.db.RevokeApiKey(ctx, queries.RevokeApiKeyParams{
+		ID:        id.UUID(),
+		RevokedAt: sql.NullInt64{Int64: time.Now().Unix(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("revoke api key: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+func generateToken() (string, error) {
+	by := make([]byte, 32)
+	if _, err := rand.Read(by); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(by), nil
+}
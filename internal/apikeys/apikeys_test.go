@@ -0,0 +1,32 @@
+package apikeys_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItVerifiesAndRevokesKeys(t *testing.T) {
+	b := test.Boiler(t)
+	keys := boiler.MustResolve[*apikeys.Keys](b)
+
+	key, token := test.ApiKey(t, b)
+
+	verified, err := keys.Verify(context.Background(), token)
+	require.Nil(t, err)
+	require.Equal(t, key.ID, verified.ID)
+
+	_, err = keys.Verify(context.Background(), "sk_bongo")
+	require.ErrorIs(t, err, apikeys.ErrInvalidKey)
+
+	require.Nil(t, keys.Revoke(context.Background(), key.ID))
+	_, err = keys.Verify(context.Background(), token)
+	require.ErrorIs(t, err, apikeys.ErrInvalidKey)
+
+	require.ErrorIs(t, keys.Revoke(context.Background(), key.ID), sql.ErrNoRows)
+}
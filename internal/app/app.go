@@ -7,16 +7,23 @@ import (
 	"github.com/henrywhitaker3/boiler"
 	gocache "github.com/henrywhitaker3/go-cache"
 	"github.com/henrywhitaker3/shorturl/database/queries"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/archive"
 	"github.com/henrywhitaker3/shorturl/internal/config"
 	"github.com/henrywhitaker3/shorturl/internal/crypto"
+	"github.com/henrywhitaker3/shorturl/internal/geoip"
 	ohttp "github.com/henrywhitaker3/shorturl/internal/http"
+	"github.com/henrywhitaker3/shorturl/internal/idempotency"
 	"github.com/henrywhitaker3/shorturl/internal/metrics"
 	"github.com/henrywhitaker3/shorturl/internal/postgres"
+	"github.com/henrywhitaker3/shorturl/internal/privacy"
 	"github.com/henrywhitaker3/shorturl/internal/probes"
 	"github.com/henrywhitaker3/shorturl/internal/queue"
 	"github.com/henrywhitaker3/shorturl/internal/redis"
+	"github.com/henrywhitaker3/shorturl/internal/screening"
 	"github.com/henrywhitaker3/shorturl/internal/storage"
 	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/webhooks"
 	"github.com/henrywhitaker3/shorturl/internal/workers"
 	"github.com/redis/rueidis"
 	"github.com/thanos-io/objstore"
@@ -49,10 +56,21 @@ func RegisterBase(b *boiler.Boiler) {
 	}
 	if *conf.Storage.Enabled {
 		boiler.MustRegister(b, RegisterStorage)
+		boiler.MustRegisterDeferred(b, RegisterArchiver)
 	}
 	boiler.MustRegisterDeferred(b, RegisterAlias)
 	boiler.MustRegisterDeferred(b, RegisterUrls)
 	boiler.MustRegisterDeferred(b, RegisterClicks)
+	boiler.MustRegisterDeferred(b, RegisterAnonymiser)
+	boiler.MustRegisterDeferred(b, RegisterGeoIP)
+	boiler.MustRegisterDeferred(b, RegisterVisitors)
+	boiler.MustRegisterDeferred(b, RegisterClickStream)
+	boiler.MustRegisterDeferred(b, RegisterLeaderboard)
+	boiler.MustRegisterDeferred(b, RegisterWebhooks)
+	boiler.MustRegisterDeferred(b, RegisterApiKeys)
+	boiler.MustRegisterDeferred(b, RegisterStatuses)
+	boiler.MustRegisterDeferred(b, RegisterIdempotency)
+	boiler.MustRegisterDeferred(b, RegisterScreener)
 	boiler.MustRegisterDeferred(b, RegisterGenerator)
 	if *conf.Queue.Enabled {
 		boiler.MustRegister(b, RegisterQueue)
@@ -68,6 +86,7 @@ func RegisterQueueHandlers(b *boiler.Boiler) {
 	boiler.MustRegisterNamedDefered(b, DefaultQueue, RegisterDefaultQueueWorker)
 	boiler.MustRegisterNamedDefered(b, CreateQueue, RegisterCreateQueueWorker)
 	boiler.MustRegisterNamedDefered(b, ClickQueue, RegisterClickQueueWorker)
+	boiler.MustRegisterNamedDefered(b, WebhookQueue, RegisterWebhookQueueWorker)
 }
 
 func RegisterDB(b *boiler.Boiler) (*sql.DB, error) {
@@ -150,26 +169,186 @@ func RegisterUrls(b *boiler.Boiler) (urls.Urls, error) {
 		return nil, err
 	}
 
+	var hooks *webhooks.Webhooks
+	if *config.Webhooks.Enabled {
+		hooks, err = boiler.Resolve[*webhooks.Webhooks](b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	svc := urls.New(urls.ServiceOpts{
-		DB:    q,
-		Conn:  db,
-		Alias: alias,
+		DB:       q,
+		Conn:     db,
+		Alias:    alias,
+		Webhooks: hooks,
 	})
 
-	return urls.NewCache(urls.CacheOpts{
+	var redis rueidis.Client
+	if *config.Redis.Enabled {
+		redis, err = boiler.Resolve[rueidis.Client](b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cache, err := urls.NewCache(urls.CacheOpts{
 		Service:  svc,
 		Size:     config.Cache.Size,
 		Registry: met.Registry,
+		Redis:    redis,
 	})
+	if err != nil {
+		return nil, err
+	}
+	go cache.Listen(b.Context())
+
+	return cache, nil
 }
 
 func RegisterClicks(b *boiler.Boiler) (*urls.Clicks, error) {
-	db, err := boiler.Resolve[*queries.Queries](b)
+	q, err := boiler.Resolve[*queries.Queries](b)
+	if err != nil {
+		return nil, err
+	}
+	db, err := boiler.Resolve[*sql.DB](b)
 	if err != nil {
 		return nil, err
 	}
 
 	return urls.NewClicks(urls.ClickOpts{
+		DB:   q,
+		Conn: db,
+	}), nil
+}
+
+func RegisterVisitors(b *boiler.Boiler) (*urls.Visitors, error) {
+	redis, err := boiler.Resolve[rueidis.Client](b)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := boiler.Resolve[*config.Config](b)
+	if err != nil {
+		return nil, err
+	}
+
+	return urls.NewVisitors(urls.VisitorsOpts{
+		Redis: redis,
+		TTL:   conf.Tracking.Visitors.TTL,
+	}), nil
+}
+
+func RegisterClickStream(b *boiler.Boiler) (*urls.ClickStream, error) {
+	redis, err := boiler.Resolve[rueidis.Client](b)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := boiler.Resolve[*config.Config](b)
+	if err != nil {
+		return nil, err
+	}
+
+	return urls.NewClickStream(urls.ClickStreamOpts{
+		Redis:     redis,
+		MaxPerKey: conf.Tracking.Stream.MaxPerKey,
+		Buffer:    conf.Tracking.Stream.Buffer,
+	}), nil
+}
+
+func RegisterLeaderboard(b *boiler.Boiler) (*urls.Leaderboard, error) {
+	redis, err := boiler.Resolve[rueidis.Client](b)
+	if err != nil {
+		return nil, err
+	}
+
+	return urls.NewLeaderboard(urls.LeaderboardOpts{
+		Redis: redis,
+	}), nil
+}
+
+func RegisterWebhooks(b *boiler.Boiler) (*webhooks.Webhooks, error) {
+	db, err := boiler.Resolve[*queries.Queries](b)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := boiler.Resolve[*config.Config](b)
+	if err != nil {
+		return nil, err
+	}
+
+	var publisher *queue.Publisher
+	if *conf.Queue.Enabled {
+		publisher, err = boiler.Resolve[*queue.Publisher](b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return webhooks.New(webhooks.WebhooksOpts{
+		DB:    db,
+		Queue: publisher,
+	}), nil
+}
+
+func RegisterStatuses(b *boiler.Boiler) (*urls.Statuses, error) {
+	cache, err := boiler.Resolve[*gocache.Cache](b)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := boiler.Resolve[*config.Config](b)
+	if err != nil {
+		return nil, err
+	}
+
+	return urls.NewStatuses(urls.StatusesOpts{
+		Cache: cache,
+		TTL:   conf.Queue.StatusTTL,
+	}), nil
+}
+
+func RegisterIdempotency(b *boiler.Boiler) (*idempotency.Store, error) {
+	redis, err := boiler.Resolve[rueidis.Client](b)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := boiler.Resolve[*config.Config](b)
+	if err != nil {
+		return nil, err
+	}
+
+	return idempotency.New(idempotency.StoreOpts{
+		Redis:   redis,
+		TTL:     conf.Http.IdempotencyTTL,
+		LockTTL: conf.Http.IdempotencyLockTTL,
+	}), nil
+}
+
+func RegisterScreener(b *boiler.Boiler) (*screening.Screener, error) {
+	conf, err := boiler.Resolve[*config.Config](b)
+	if err != nil {
+		return nil, err
+	}
+
+	screener := screening.New()
+	if conf.Screening.Blocklist != "" {
+		list, err := screening.NewBlocklist(conf.Screening.Blocklist)
+		if err != nil {
+			return nil, err
+		}
+		go list.Watch(b.Context(), conf.Screening.ReloadInterval)
+		screener.Register(list)
+	}
+
+	return screener, nil
+}
+
+func RegisterApiKeys(b *boiler.Boiler) (*apikeys.Keys, error) {
+	db, err := boiler.Resolve[*queries.Queries](b)
+	if err != nil {
+		return nil, err
+	}
+
+	return apikeys.New(apikeys.KeysOpts{
 		DB: db,
 	}), nil
 }
@@ -207,6 +386,10 @@ func RegisterQueue(b *boiler.Boiler) (*queue.Publisher, error) {
 	if err != nil {
 		return nil, err
 	}
+	batched := []queue.Task{}
+	if *conf.Tracking.Batch.Enabled {
+		batched = append(batched, queue.ClickTask)
+	}
 	return queue.NewPublisher(queue.PublisherOpts{
 		Redis: queue.RedisOpts{
 			Addr:        conf.Redis.Addr,
@@ -214,6 +397,7 @@ func RegisterQueue(b *boiler.Boiler) (*queue.Publisher, error) {
 			DB:          conf.Queue.DB,
 			OtelEnabled: *conf.Telemetry.Tracing.Enabled,
 		},
+		Batched: batched,
 	})
 }
 
@@ -243,11 +427,13 @@ func RegisterGenerator(b *boiler.Boiler) (*urls.AliasGenerator, error) {
 	}
 
 	gen := urls.NewAliasGenerator(urls.AliasGeneratorOpts{
-		Alias:      alias,
-		BufferSize: conf.Generator.BufferSize,
-		Interval:   conf.Generator.Interval,
-		Length:     conf.Generator.Length,
-		Registry:   met.Registry,
+		Alias:         alias,
+		BufferSize:    conf.Generator.BufferSize,
+		Interval:      conf.Generator.Interval,
+		Length:        conf.Generator.Length,
+		Alphabet:      conf.Generator.Characters(),
+		GrowThreshold: conf.Generator.GrowThreshold,
+		Registry:      met.Registry,
 	})
 
 	return gen, nil
@@ -277,9 +463,46 @@ func RegisterRunner(b *boiler.Boiler) (*workers.Runner, error) {
 		return nil, err
 	}
 
+	var archiver *archive.Archiver
+	if config.Tracking.Retention.Archive {
+		archiver, err = boiler.Resolve[*archive.Archiver](b)
+		if err != nil {
+			return nil, err
+		}
+	}
 	retention := urls.NewRetention(urls.RetentionOpts{
+		Clicks:   clicks,
+		Archiver: archiver,
+		Config:   config.Tracking.Retention,
+	})
+
+	rollup := urls.NewRollup(urls.RollupOpts{
 		Clicks: clicks,
-		Config: config.Tracking.Retention,
+		Config: config.Tracking.Rollup,
+	})
+
+	partitions := urls.NewPartitions(urls.PartitionsOpts{
+		Clicks: clicks,
+		Config: config.Tracking.Partitions,
+	})
+
+	svc, err := boiler.Resolve[urls.Urls](b)
+	if err != nil {
+		return nil, err
+	}
+	expiry := urls.NewExpiry(urls.ExpiryOpts{
+		Urls:   svc,
+		Config: config.Expiry.Sweep,
+	})
+
+	screener, err := boiler.Resolve[*screening.Screener](b)
+	if err != nil {
+		return nil, err
+	}
+	screen := urls.NewScreening(urls.ScreeningOpts{
+		Urls:     svc,
+		Screener: screener,
+		Interval: config.Screening.ScanInterval,
 	})
 
 	if err := runner.Register(gen); err != nil {
@@ -288,6 +511,18 @@ func RegisterRunner(b *boiler.Boiler) (*workers.Runner, error) {
 	if err := runner.Register(retention); err != nil {
 		return nil, fmt.Errorf("failed to register retention worker: %w", err)
 	}
+	if err := runner.Register(rollup); err != nil {
+		return nil, fmt.Errorf("failed to register rollup worker: %w", err)
+	}
+	if err := runner.Register(partitions); err != nil {
+		return nil, fmt.Errorf("failed to register partitions worker: %w", err)
+	}
+	if err := runner.Register(expiry); err != nil {
+		return nil, fmt.Errorf("failed to register expiry worker: %w", err)
+	}
+	if err := runner.Register(screen); err != nil {
+		return nil, fmt.Errorf("failed to register screening worker: %w", err)
+	}
 
 	return runner, nil
 }
@@ -300,10 +535,32 @@ func RegisterStorage(b *boiler.Boiler) (objstore.Bucket, error) {
 	return storage.New(conf.Storage)
 }
 
+func RegisterArchiver(b *boiler.Boiler) (*archive.Archiver, error) {
+	db, err := boiler.Resolve[*queries.Queries](b)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := boiler.Resolve[objstore.Bucket](b)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := boiler.Resolve[*config.Config](b)
+	if err != nil {
+		return nil, err
+	}
+
+	return archive.New(archive.ArchiverOpts{
+		DB:           db,
+		Bucket:       bucket,
+		KeepRestored: conf.Tracking.Retention.KeepRestored,
+	}), nil
+}
+
 const (
 	DefaultQueue = "queue:default"
 	CreateQueue  = "queue:create"
 	ClickQueue   = "queue:click"
+	WebhookQueue = "queue:webhook"
 )
 
 func RegisterDefaultQueueWorker(
@@ -345,7 +602,20 @@ func RegisterCreateQueueWorker(
 	if err != nil {
 		return nil, err
 	}
-	handler := urls.NewCreateJobHandler(svc)
+	statuses, err := boiler.Resolve[*urls.Statuses](b)
+	if err != nil {
+		return nil, err
+	}
+	screener, err := boiler.Resolve[*screening.Screener](b)
+	if err != nil {
+		return nil, err
+	}
+	alias, err := boiler.Resolve[*urls.Alias](b)
+	if err != nil {
+		return nil, err
+	}
+	handler := urls.NewCreateJobHandler(svc, statuses, screener, alias)
+	batch := urls.NewCreateBatchJobHandler(svc, statuses, screener, alias)
 
 	worker, err := queue.NewWorker(b.Context(), queue.ServerOpts{
 		Redis: queue.RedisOpts{
@@ -361,9 +631,39 @@ func RegisterCreateQueueWorker(
 		return nil, err
 	}
 	worker.RegisterHandler(queue.CreateTask, handler)
+	worker.RegisterHandler(queue.CreateBatchTask, batch)
 	return worker, nil
 }
 
+func RegisterAnonymiser(b *boiler.Boiler) (*privacy.Anonymiser, error) {
+	conf, err := boiler.Resolve[*config.Config](b)
+	if err != nil {
+		return nil, err
+	}
+	var redis rueidis.Client
+	if *conf.Redis.Enabled {
+		redis, err = boiler.Resolve[rueidis.Client](b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return privacy.New(privacy.AnonymiserOpts{
+		Mode:  privacy.Mode(conf.Tracking.Privacy.Mode),
+		Redis: redis,
+	}), nil
+}
+
+// Only resolve when a database is configured
+func RegisterGeoIP(b *boiler.Boiler) (*geoip.Reader, error) {
+	conf, err := boiler.Resolve[*config.Config](b)
+	if err != nil {
+		return nil, err
+	}
+	geo := geoip.New(conf.Tracking.GeoIP.Database)
+	go geo.Watch(b.Context(), conf.Tracking.GeoIP.ReloadInterval)
+	return geo, nil
+}
+
 func RegisterClickQueueWorker(
 	b *boiler.Boiler,
 ) (*queue.Worker, error) {
@@ -380,7 +680,54 @@ func RegisterClickQueueWorker(
 	if err != nil {
 		return nil, err
 	}
-	handler := urls.NewClickJobHandler(svc)
+	var geo *geoip.Reader
+	if conf.Tracking.GeoIP.Database != "" {
+		geo, err = boiler.Resolve[*geoip.Reader](b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	anonymiser, err := boiler.Resolve[*privacy.Anonymiser](b)
+	if err != nil {
+		return nil, err
+	}
+	var stream *urls.ClickStream
+	if *conf.Tracking.Stream.Enabled {
+		stream, err = boiler.Resolve[*urls.ClickStream](b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var hooks *webhooks.Webhooks
+	if *conf.Webhooks.Enabled {
+		hooks, err = boiler.Resolve[*webhooks.Webhooks](b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var top *urls.Leaderboard
+	if *conf.Tracking.Leaderboard.Enabled {
+		top, err = boiler.Resolve[*urls.Leaderboard](b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	handler := urls.NewClickJobHandler(urls.ClickJobHandlerOpts{
+		Clicks:      svc,
+		Anonymiser:  anonymiser,
+		Geo:         geo,
+		Stream:      stream,
+		Webhooks:    hooks,
+		Leaderboard: top,
+	})
+
+	var batch *queue.BatchOpts
+	if *conf.Tracking.Batch.Enabled {
+		batch = &queue.BatchOpts{
+			MaxSize:  conf.Tracking.Batch.Size,
+			MaxDelay: conf.Tracking.Batch.Interval,
+		}
+	}
 
 	worker, err := queue.NewWorker(b.Context(), queue.ServerOpts{
 		Redis: queue.RedisOpts{
@@ -391,10 +738,53 @@ func RegisterClickQueueWorker(
 		},
 		Queues:      []queue.Queue{queue.Click},
 		Concurrency: conc,
+		Batch:       batch,
 	})
 	if err != nil {
 		return nil, err
 	}
+	// Clicks queued before batching was enabled are still stored one by one
 	worker.RegisterHandler(queue.ClickTask, handler)
+	worker.RegisterHandler(queue.ClickBatchTask, urls.NewClickBatchJobHandler(handler))
+	return worker, nil
+}
+
+func RegisterWebhookQueueWorker(
+	b *boiler.Boiler,
+) (*queue.Worker, error) {
+	conf, err := boiler.Resolve[*config.Config](b)
+	if err != nil {
+		return nil, err
+	}
+	conc := 0
+	if conf.Queue.Concurrency != nil {
+		conc = *conf.Queue.Concurrency
+	}
+
+	db, err := boiler.Resolve[*queries.Queries](b)
+	if err != nil {
+		return nil, err
+	}
+
+	worker, err := queue.NewWorker(b.Context(), queue.ServerOpts{
+		Redis: queue.RedisOpts{
+			Addr:        conf.Redis.Addr,
+			Password:    conf.Redis.Password,
+			DB:          conf.Queue.DB,
+			OtelEnabled: *conf.Telemetry.Tracing.Enabled,
+		},
+		Queues:      []queue.Queue{queue.Webhook},
+		Concurrency: conc,
+		RetryDelay:  queue.Backoff(conf.Webhooks.Backoff, conf.Webhooks.MaxBackoff),
+	})
+	if err != nil {
+		return nil, err
+	}
+	worker.RegisterHandler(queue.WebhookTask, webhooks.NewDeliveryHandler(webhooks.DeliveryHandlerOpts{
+		DB:           db,
+		Timeout:      conf.Webhooks.Timeout,
+		MaxAttempts:  conf.Webhooks.MaxAttempts,
+		AllowPrivate: conf.Webhooks.AllowPrivate,
+	}))
 	return worker, nil
 }
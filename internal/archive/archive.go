@@ -0,0 +1,345 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/database/queries"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/thanos-io/objstore"
+)
+
+const (
+	day = time.Hour * 24
+	// The number of clicks read from the database at a time
+	pageSize = 5000
+)
+
+// An archived click, in the same shape as the clicks table
+type Click struct {
+	ID             uuid.UUID `json:"id"`
+	UrlID          uuid.UUID `json:"url_id"`
+	IP             string    `json:"ip"`
+	ClickedAt      int64     `json:"clicked_at"`
+	Referrer       string    `json:"referrer"`
+	ReferrerDomain string    `json:"referrer_domain"`
+	UserAgent      string    `json:"user_agent"`
+	Language       string    `json:"language"`
+	Browser        string    `json:"browser"`
+	OS             string    `json:"os"`
+	Device         string    `json:"device"`
+	Bot            bool      `json:"bot"`
+	Country        string    `json:"country"`
+	Region         string    `json:"region"`
+	City           string    `json:"city"`
+}
+
+// Writes clicks to the bucket as gzipped NDJSON files under a directory per
+// day, clicks/date=YYYY-MM-DD/. Existing files are never overwritten, each
+// run writes a new part with the clicks that haven't been archived yet.
+type Archiver struct {
+	db           *queries.Queries
+	bucket       objstore.Bucket
+	keepRestored time.Duration
+}
+
+type ArchiverOpts struct {
+	DB     *queries.Queries
+	Bucket objstore.Bucket
+	// How long restored days are kept before retention can delete them
+	// again
+	KeepRestored time.Duration
+}
+
+func New(opts ArchiverOpts) *Archiver {
+	return &Archiver{
+		db:           opts.DB,
+		bucket:       opts.Bucket,
+		keepRestored: opts.KeepRestored,
+	}
+}
+
+var ErrPartialDay = errors.New("range must cover whole days")
+
+// The directory the parts for the day are written to
+func Dir(date time.Time) string {
+	return fmt.Sprintf("clicks/date=%s/", date.UTC().Format(time.DateOnly))
+}
+
+func partKey(date time.Time, part uuid.UUID) string {
+	return fmt.Sprintf("%sclicks-%s.ndjson.gz", Dir(date), part)
+}
+
+// Archives every whole day of clicks before the cutoff, which is rounded
+// down to the start of the day. Clicks that have already been archived are
+// skipped, so clicks that arrived late or were restored are only archived
+// once. Days that are being kept after a restore are skipped until they
+// expire. Returns the number of days that new clicks were archived for.
+func (a *Archiver) Archive(ctx context.Context, before time.Time) (int, error) {
+	oldest, err := a.db.GetOldestClickTime(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("get oldest click: %w", err)
+	}
+	restored, err := a.db.ListRestoredClickDays(ctx, time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("list restored days: %w", err)
+	}
+
+	cutoff := before.UTC().Truncate(day)
+	archived := 0
+	for date := time.Unix(oldest, 0).UTC().Truncate(day); date.Before(cutoff); date = date.Add(day) {
+		if slices.Contains(restored, date.Unix()) {
+			continue
+		}
+		written, err := a.archiveDay(ctx, date)
+		if err != nil {
+			return archived, fmt.Errorf("archive clicks for %s: %w", date.Format(time.DateOnly), err)
+		}
+		if written > 0 {
+			archived++
+		}
+	}
+	return archived, nil
+}
+
+func (a *Archiver) archiveDay(ctx context.Context, date time.Time) (int, error) {
+	existing := map[uuid.UUID]struct{}{}
+	if err := a.read(ctx, date, func(click *Click) error {
+		existing[click.ID] = struct{}{}
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("read existing archive: %w", err)
+	}
+
+	part, err := uuid.Ordered()
+	if err != nil {
+		return 0, fmt.Errorf("generate part id: %w", err)
+	}
+	key := partKey(date, part)
+
+	reader, writer := io.Pipe()
+	written := 0
+	go func() {
+		var err error
+		written, err = a.write(ctx, writer, date, existing)
+		writer.CloseWithError(err)
+	}()
+
+	err = a.bucket.Upload(ctx, key, reader)
+	// Stop the writer if the upload failed part way through
+	reader.CloseWithError(err)
+	if err != nil {
+		return 0, err
+	}
+
+	if written == 0 {
+		// Every click for the day had already been archived
+		if err := a.bucket.Delete(ctx, key); err != nil {
+			return 0, fmt.Errorf("delete empty part: %w", err)
+		}
+	}
+	return written, nil
+}
+
+// Writes the clicks for the day that aren't in skip, returning the number
+// of clicks written
+func (a *Archiver) write(ctx context.Context, w io.Writer, date time.Time, skip map[uuid.UUID]struct{}) (int, error) {
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+
+	written := 0
+	after := uuid.UUID{}
+	for {
+		clicks, err := a.db.ListClicksBetween(ctx, queries.ListClicksBetweenParams{
+			Since: date.Unix(),
+			Until: date.Add(day).Unix(),
+			After: after.UUID(),
+			Count: pageSize,
+		})
+		if err != nil {
+			return written, fmt.Errorf("list clicks: %w", err)
+		}
+		for _, click := range clicks {
+			after = uuid.UUID(click.ID)
+			if _, ok := skip[after]; ok {
+				continue
+			}
+			if err := enc.Encode(fromRow(click)); err != nil {
+				return written, fmt.Errorf("encode click: %w", err)
+			}
+			written++
+		}
+		if len(clicks) < pageSize {
+			break
+		}
+	}
+
+	return written, gz.Close()
+}
+
+// Calls fn for every archived click between from and to. Days without an
+// archive are skipped.
+func (a *Archiver) Query(ctx context.Context, from, to time.Time, fn func(*Click) error) error {
+	for date := from.UTC().Truncate(day); date.Before(to); date = date.Add(day) {
+		if err := a.read(ctx, date, func(click *Click) error {
+			if click.ClickedAt < from.Unix() || click.ClickedAt >= to.Unix() {
+				return nil
+			}
+			return fn(click)
+		}); err != nil {
+			return fmt.Errorf("read archive for %s: %w", date.Format(time.DateOnly), err)
+		}
+	}
+	return nil
+}
+
+// Calls fn for every click in each part archived for the day
+func (a *Archiver) read(ctx context.Context, date time.Time, fn func(*Click) error) error {
+	return a.bucket.Iter(ctx, Dir(date), func(name string) error {
+		if strings.HasSuffix(name, objstore.DirDelim) {
+			return nil
+		}
+		if err := a.readPart(ctx, name, fn); err != nil {
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+func (a *Archiver) readPart(ctx context.Context, name string, fn func(*Click) error) error {
+	body, err := a.bucket.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("open gzip: %w", err)
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		click := &Click{}
+		if err := json.Unmarshal(scanner.Bytes(), click); err != nil {
+			return fmt.Errorf("decode click: %w", err)
+		}
+		if err := fn(click); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Restores the archived clicks between from and to into the clicks table,
+// creating their partitions. The range must cover whole days, so that the
+// days can be archived again without losing clicks. The days are kept for
+// the keep restored period before retention can delete them again. Clicks
+// that already exist are skipped. Returns the number of clicks restored.
+func (a *Archiver) Restore(ctx context.Context, from, to time.Time) (int, error) {
+	if !from.Equal(from.Truncate(day)) || !to.Equal(to.Truncate(day)) {
+		return 0, ErrPartialDay
+	}
+
+	// Mark the days before creating their partitions, so retention can't
+	// drop them in between
+	days := []int64{}
+	for date := from.UTC(); date.Before(to); date = date.Add(day) {
+		days = append(days, date.Unix())
+	}
+	if err := a.db.KeepRestoredClickDays(ctx, queries.KeepRestoredClickDaysParams{
+		Days:      days,
+		KeepUntil: time.Now().Add(a.keepRestored).Unix(),
+	}); err != nil {
+		return 0, fmt.Errorf("keep restored days: %w", err)
+	}
+	for date := from.UTC(); date.Before(to); date = date.Add(day) {
+		if err := a.db.CreateClicksPartition(ctx, date.Unix()); err != nil {
+			return 0, fmt.Errorf("create clicks partition: %w", err)
+		}
+	}
+
+	restored := 0
+	batch := []*Click{}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		inserted, err := a.db.StoreClicks(ctx, toParams(batch))
+		if err != nil {
+			return fmt.Errorf("store clicks: %w", err)
+		}
+		restored += int(inserted)
+		batch = batch[:0]
+		return nil
+	}
+
+	if err := a.Query(ctx, from, to, func(click *Click) error {
+		batch = append(batch, click)
+		if len(batch) >= pageSize {
+			return flush()
+		}
+		return nil
+	}); err != nil {
+		return restored, err
+	}
+	return restored, flush()
+}
+
+func fromRow(row *queries.Click) *Click {
+	return &Click{
+		ID:             uuid.UUID(row.ID),
+		UrlID:          uuid.UUID(row.UrlID),
+		IP:             row.Ip,
+		ClickedAt:      row.ClickedAt,
+		Referrer:       row.Referrer,
+		ReferrerDomain: row.ReferrerDomain,
+		UserAgent:      row.UserAgent,
+		Language:       row.Language,
+		Browser:        row.Browser,
+		OS:             row.Os,
+		Device:         row.Device,
+		Bot:            row.Bot,
+		Country:        row.Country,
+		Region:         row.Region,
+		City:           row.City,
+	}
+}
+
+func toParams(clicks []*Click) queries.StoreClicksParams {
+	// Clicks were rolled up before they were archived, so restoring them
+	// mustn't add them to the rollups again
+	args := queries.StoreClicksParams{RolledUp: true}
+	for _, click := range clicks {
+		args.Ids = append(args.Ids, click.ID.UUID())
+		args.UrlIds = append(args.UrlIds, click.UrlID.UUID())
+		args.Ips = append(args.Ips, click.IP)
+		args.ClickedAts = append(args.ClickedAts, click.ClickedAt)
+		args.Referrers = append(args.Referrers, click.Referrer)
+		args.ReferrerDomains = append(args.ReferrerDomains, click.ReferrerDomain)
+		args.UserAgents = append(args.UserAgents, click.UserAgent)
+		args.Languages = append(args.Languages, click.Language)
+		args.Browsers = append(args.Browsers, click.Browser)
+		args.Oses = append(args.Oses, click.OS)
+		args.Devices = append(args.Devices, click.Device)
+		args.Bots = append(args.Bots, click.Bot)
+		args.Countries = append(args.Countries, click.Country)
+		args.Regions = append(args.Regions, click.Region)
+		args.Cities = append(args.Cities, click.City)
+	}
+	return args
+}
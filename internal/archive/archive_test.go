@@ -0,0 +1,122 @@
+package archive_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/archive"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItArchivesAndRestoresClicks(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	url := test.Url(t, b, test.UrlOpts{})
+	clicks := boiler.MustResolve[*urls.Clicks](b)
+	archiver := boiler.MustResolve[*archive.Archiver](b)
+
+	today := time.Now().UTC().Truncate(time.Hour * 24)
+	clickedAt := today.Add(-time.Hour * 36)
+
+	require.Nil(t, clicks.CreatePartitions(ctx, clickedAt, time.Now()))
+	require.Nil(t, clicks.Click(ctx, urls.StoreClick{
+		ID:   url.ID,
+		IP:   "127.0.0.1",
+		Time: clickedAt,
+	}))
+	require.Nil(t, clicks.Click(ctx, urls.StoreClick{
+		ID:   url.ID,
+		IP:   "127.0.0.1",
+		Time: time.Now(),
+	}))
+
+	days, err := archiver.Archive(ctx, today)
+	require.Nil(t, err)
+	// Other tests may have stored older clicks
+	require.GreaterOrEqual(t, days, 2)
+
+	archived := []*archive.Click{}
+	require.Nil(t, archiver.Query(ctx, today.Add(-time.Hour*48), today, func(c *archive.Click) error {
+		if c.UrlID == url.ID {
+			archived = append(archived, c)
+		}
+		return nil
+	}))
+	require.Len(t, archived, 1)
+	require.Equal(t, url.ID, archived[0].UrlID)
+	require.Equal(t, clickedAt.Unix(), archived[0].ClickedAt)
+
+	count := func() int {
+		series, err := clicks.Series(ctx, urls.SeriesParams{
+			ID:          url.ID,
+			Granularity: urls.Day,
+			Since:       today.Add(-time.Hour * 48),
+			Until:       today,
+		})
+		require.Nil(t, err)
+		total := 0
+		for _, bucket := range series {
+			total += bucket.Clicks
+		}
+		return total
+	}
+	require.Nil(t, clicks.Rollup(ctx, 0))
+	require.Equal(t, 1, count())
+
+	_, err = clicks.Delete(ctx, today)
+	require.Nil(t, err)
+
+	_, err = archiver.Restore(ctx, today.Add(-time.Hour*36), today)
+	require.ErrorIs(t, err, archive.ErrPartialDay)
+
+	restored, err := archiver.Restore(ctx, today.Add(-time.Hour*48), today)
+	require.Nil(t, err)
+	require.Equal(t, 1, restored)
+
+	// Restored clicks were already rolled up, so aren't counted again
+	require.Nil(t, clicks.Rollup(ctx, 0))
+	require.Equal(t, 1, count())
+
+	// Archiving the restored day again keeps the clicks archived once
+	_, err = archiver.Archive(ctx, today)
+	require.Nil(t, err)
+	archived = []*archive.Click{}
+	require.Nil(t, archiver.Query(ctx, today.Add(-time.Hour*48), today, func(c *archive.Click) error {
+		if c.UrlID == url.ID {
+			archived = append(archived, c)
+		}
+		return nil
+	}))
+	require.Len(t, archived, 1)
+
+	// Retention keeps the restored day instead of dropping it again
+	retention := urls.NewRetention(urls.RetentionOpts{
+		Clicks:   clicks,
+		Archiver: archiver,
+		Config: config.Retention{
+			Enabled: true,
+			Period:  time.Hour * 24,
+		},
+	})
+	require.Nil(t, retention.Run(ctx))
+	raw, err := clicks.Series(ctx, urls.SeriesParams{
+		ID:          url.ID,
+		Granularity: urls.Minute,
+		Since:       today.Add(-time.Hour * 48),
+		Until:       today.Add(-time.Hour * 24),
+	})
+	require.Nil(t, err)
+	total := 0
+	for _, bucket := range raw {
+		total += bucket.Clicks
+	}
+	require.Equal(t, 1, total)
+}
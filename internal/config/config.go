@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -162,6 +163,14 @@ type Probes struct {
 
 type Http struct {
 	Port int `yaml:"port" env:"PORT, overwrite, default=8765"`
+	// Create urls inline instead of queueing them, can be set per
+	// request with ?wait=true
+	SyncCreate bool `yaml:"sync_create" env:"SYNC_CREATE, overwrite, default=false"`
+	// How long Idempotency-Key headers are remembered for
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl" env:"IDEMPOTENCY_TTL, overwrite, default=24h"`
+	// How long an Idempotency-Key is locked for while its request is in
+	// progress, so retries aren't blocked if the request never finishes
+	IdempotencyLockTTL time.Duration `yaml:"idempotency_lock_ttl" env:"IDEMPOTENCY_LOCK_TTL, overwrite, default=1m"`
 }
 
 type Storage struct {
@@ -179,16 +188,62 @@ type Queue struct {
 	Enabled     *bool `yaml:"enabled"     env:"ENABLED, overwrite, default=true"`
 	DB          int   `yaml:"db"          env:"DB, overwrite, default=5"`
 	Concurrency *int  `yaml:"concurrency" env:"CONCURRENCY, overwrite"`
+	// How long the status of a queued url creation is kept for
+	StatusTTL time.Duration `yaml:"status_ttl" env:"STATUS_TTL, overwrite, default=24h"`
 }
 
 type Runner struct {
 	Enabled *bool `yaml:"enabled" env:"ENABLED, overwrite, default=true"`
 }
 
+const (
+	Base36Alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	Base62Alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
 type Generator struct {
 	Length     int           `yaml:"length"      env:"LENGTH, overwrite, default=5"`
 	BufferSize int           `yaml:"buffer_size" env:"BUFFER_SIZE, overwrite, default=100000"`
 	Interval   time.Duration `yaml:"interval"    env:"INTERVAL, overwrite, default=5s"`
+	// Either base36, base62 or the characters to generate aliases from
+	Alphabet string `yaml:"alphabet" env:"ALPHABET, overwrite, default=base36"`
+	// The fraction of the aliases of the current length that can be used
+	// before generating aliases one character longer
+	GrowThreshold float64 `yaml:"grow_threshold" env:"GROW_THRESHOLD, overwrite, default=0.5"`
+}
+
+// Returns the characters aliases are generated from
+func (g Generator) Characters() string {
+	switch g.Alphabet {
+	case "base36":
+		return Base36Alphabet
+	case "base62":
+		return Base62Alphabet
+	default:
+		return g.Alphabet
+	}
+}
+
+func (g Generator) validate() error {
+	if g.Length < 1 {
+		return errors.New("generator length must be at least 1")
+	}
+	if g.GrowThreshold <= 0 || g.GrowThreshold > 1 {
+		return errors.New("generator grow threshold must be greater than 0 and at most 1")
+	}
+	chars := g.Characters()
+	if len(chars) < 2 {
+		return errors.New("generator alphabet must have at least 2 characters")
+	}
+	for i, char := range chars {
+		if !strings.ContainsRune(Base62Alphabet+"-_", char) {
+			return fmt.Errorf("generator alphabet cannot contain %q", char)
+		}
+		if strings.ContainsRune(chars[i+1:], char) {
+			return fmt.Errorf("generator alphabet contains %q more than once", char)
+		}
+	}
+	return nil
 }
 
 type Cache struct {
@@ -198,11 +253,126 @@ type Cache struct {
 type Retention struct {
 	Enabled bool          `yaml:"enabled" env:"ENABLED, overwrite, default=true"`
 	Period  time.Duration `yaml:"period"  env:"PERIOD, overwrite, default=48h"`
+	// Write clicks to the storage bucket before they are deleted
+	Archive bool `yaml:"archive" env:"ARCHIVE, overwrite, default=false"`
+	// How long days restored from the archive are kept before they are
+	// deleted again
+	KeepRestored time.Duration `yaml:"keep_restored" env:"KEEP_RESTORED, overwrite, default=168h"`
+}
+
+type GeoIP struct {
+	// The path to a MaxMind City or Country database, clicks aren't
+	// located when empty
+	Database string `yaml:"database" env:"DATABASE, overwrite"`
+	// How often the database is checked for changes
+	ReloadInterval time.Duration `yaml:"reload_interval" env:"RELOAD_INTERVAL, overwrite, default=1m"`
+}
+
+type Privacy struct {
+	// How visitor ips are stored, one of full, truncate or hash
+	Mode string `yaml:"mode" env:"MODE, overwrite, default=full"`
+	// Don't track visitors that send DNT or Sec-GPC headers
+	HonourDoNotTrack bool `yaml:"honour_do_not_track" env:"HONOUR_DO_NOT_TRACK, overwrite, default=false"`
+}
+
+type ClickBatch struct {
+	// Store clicks in batches instead of one at a time
+	Enabled *bool `yaml:"enabled"  env:"ENABLED, overwrite, default=false"`
+	// The most clicks stored in a single batch
+	Size int `yaml:"size" env:"SIZE, overwrite, default=500"`
+	// The longest a click waits before its batch is stored, minimum 1s
+	Interval time.Duration `yaml:"interval" env:"INTERVAL, overwrite, default=1s"`
+}
+
+type Rollup struct {
+	// How often the click rollups are updated
+	Interval time.Duration `yaml:"interval" env:"INTERVAL, overwrite, default=1m"`
+	// How long after clicks are stored before they are rolled up, so
+	// inserts that haven't committed yet aren't skipped
+	Delay time.Duration `yaml:"delay" env:"DELAY, overwrite, default=1m"`
+}
+
+type Partitions struct {
+	// How far ahead the daily clicks partitions are created
+	Ahead time.Duration `yaml:"ahead" env:"AHEAD, overwrite, default=168h"`
+}
+
+type Visitors struct {
+	// Count unique visitors per url per day in redis
+	Enabled *bool `yaml:"enabled" env:"ENABLED, overwrite, default=true"`
+	// How long the daily counts are kept
+	TTL time.Duration `yaml:"ttl" env:"TTL, overwrite, default=9600h"`
+}
+
+type ClickStream struct {
+	// Publish stored clicks to live streams
+	Enabled *bool `yaml:"enabled" env:"ENABLED, overwrite, default=true"`
+	// The most streams an api key, or a client when auth is disabled, can
+	// have open across every replica
+	MaxPerKey int `yaml:"max_per_key" env:"MAX_PER_KEY, overwrite, default=5"`
+	// How many clicks are queued for a slow stream before they are dropped
+	Buffer int `yaml:"buffer" env:"BUFFER, overwrite, default=64"`
+	// How often a comment is sent to keep idle streams open
+	Heartbeat time.Duration `yaml:"heartbeat" env:"HEARTBEAT, overwrite, default=15s"`
+}
+
+type Leaderboard struct {
+	// Count recent clicks per url in redis for the top urls
+	Enabled *bool `yaml:"enabled" env:"ENABLED, overwrite, default=true"`
 }
 
 type Tracking struct {
-	Enabled   bool      `yaml:"enabled"   env:"ENDABLED, overwrite, default=false"`
-	Retention Retention `yaml:"retention" env:", prefix=RETENTION_"`
+	Enabled     bool        `yaml:"enabled"   env:"ENDABLED, overwrite, default=false"`
+	Retention   Retention   `yaml:"retention" env:", prefix=RETENTION_"`
+	GeoIP       GeoIP       `yaml:"geoip"     env:", prefix=GEOIP_"`
+	Privacy     Privacy     `yaml:"privacy"   env:", prefix=PRIVACY_"`
+	Batch       ClickBatch  `yaml:"batch"     env:", prefix=BATCH_"`
+	Rollup      Rollup      `yaml:"rollup"     env:", prefix=ROLLUP_"`
+	Partitions  Partitions  `yaml:"partitions" env:", prefix=PARTITIONS_"`
+	Visitors    Visitors    `yaml:"visitors"   env:", prefix=VISITORS_"`
+	Stream      ClickStream `yaml:"stream"      env:", prefix=STREAM_"`
+	Leaderboard Leaderboard `yaml:"leaderboard" env:", prefix=LEADERBOARD_"`
+}
+
+type Webhooks struct {
+	// Send events to registered webhooks
+	Enabled *bool `yaml:"enabled" env:"ENABLED, overwrite, default=true"`
+	// How long to wait for an endpoint to respond
+	Timeout time.Duration `yaml:"timeout" env:"TIMEOUT, overwrite, default=10s"`
+	// How many times a delivery is attempted before it is marked as failed
+	MaxAttempts int `yaml:"max_attempts" env:"MAX_ATTEMPTS, overwrite, default=10"`
+	// The delay before the first retry, which doubles with each retry after
+	Backoff time.Duration `yaml:"backoff" env:"BACKOFF, overwrite, default=30s"`
+	// The longest delay between retries
+	MaxBackoff time.Duration `yaml:"max_backoff" env:"MAX_BACKOFF, overwrite, default=6h"`
+	// Allow webhooks to be sent to loopback, private and link-local
+	// addresses, which are rejected by default
+	AllowPrivate bool `yaml:"allow_private" env:"ALLOW_PRIVATE"`
+}
+
+type Auth struct {
+	// Require an api key to manage urls, visits are always public
+	Enabled *bool `yaml:"enabled" env:"ENABLED, overwrite, default=false"`
+}
+
+type Screening struct {
+	// The path to a file of blocked domains and /patterns/
+	Blocklist string `yaml:"blocklist" env:"BLOCKLIST, overwrite"`
+	// How often the blocklist is checked for changes
+	ReloadInterval time.Duration `yaml:"reload_interval" env:"RELOAD_INTERVAL, overwrite, default=30s"`
+	// How often existing urls are screened again
+	ScanInterval time.Duration `yaml:"scan_interval" env:"SCAN_INTERVAL, overwrite, default=1h"`
+}
+
+type Sweep struct {
+	Enabled bool          `yaml:"enabled" env:"ENABLED, overwrite, default=true"`
+	Period  time.Duration `yaml:"period"  env:"PERIOD, overwrite, default=168h"`
+}
+
+type Expiry struct {
+	// Where visits to an expired url are redirected, returns a 410 when empty
+	FallbackUrl string `yaml:"fallback_url" env:"FALLBACK_URL, overwrite"`
+	Sweep       Sweep  `yaml:"sweep"        env:", prefix=SWEEP_"`
 }
 
 type Config struct {
@@ -219,6 +389,10 @@ type Config struct {
 
 	Probes Probes `yaml:"probes" env:", prefix=PROBES_"`
 	Http   Http   `yaml:"http"   env:", prefix=HTTP_"`
+	Auth   Auth   `yaml:"auth"   env:", prefix=AUTH_"`
+
+	Screening Screening `yaml:"screening" env:", prefix=SCREENING_"`
+	Webhooks  Webhooks  `yaml:"webhooks"  env:", prefix=WEBHOOKS_"`
 
 	Telemetry Telemetry `yaml:"telemetry" env:", prefix=TELEMETRY_"`
 
@@ -228,6 +402,7 @@ type Config struct {
 	Generator Generator `yaml:"generator" env:", prefix=GENERATOR_"`
 	Cache     Cache     `yaml:"cache"     env:", prefix=CACHE_"`
 	Tracking  Tracking  `yaml:"tracking"  env:", prefix=TRACKING_"`
+	Expiry    Expiry    `yaml:"expiry"    env:", prefix=EXPIRY_"`
 }
 
 func Load(path string) (*Config, error) {
@@ -278,6 +453,78 @@ func (c *Config) validate() error {
 	if !(*c.Redis.Enabled) && *c.Runner.Enabled {
 		return errors.New("runner cannot be enabled without redis")
 	}
+	if !(*c.Database.Enabled) && *c.Auth.Enabled {
+		return errors.New("auth cannot be enabled without database")
+	}
+	switch c.Tracking.Privacy.Mode {
+	case "full", "truncate", "hash":
+	default:
+		return errors.New("tracking privacy mode must be one of full, truncate or hash")
+	}
+	if *c.Tracking.Batch.Enabled && c.Tracking.Batch.Size < 1 {
+		return errors.New("tracking batch size must be at least 1")
+	}
+	// asynq only checks for groups to aggregate once a second
+	if *c.Tracking.Batch.Enabled && c.Tracking.Batch.Interval < time.Second {
+		return errors.New("tracking batch interval must be at least 1s")
+	}
+	if c.Http.IdempotencyLockTTL <= 0 {
+		return errors.New("http idempotency lock ttl must be positive")
+	}
+	// The daily salt is shared between replicas through redis
+	if c.Tracking.Privacy.Mode == "hash" && !(*c.Redis.Enabled) {
+		return errors.New("tracking privacy mode hash cannot be enabled without redis")
+	}
+	if *c.Tracking.Stream.Enabled && c.Tracking.Stream.MaxPerKey < 1 {
+		return errors.New("tracking stream max per key must be at least 1")
+	}
+	if c.Tracking.GeoIP.Database != "" && c.Tracking.GeoIP.ReloadInterval <= 0 {
+		return errors.New("geoip reload interval must be positive")
+	}
+	if c.Screening.Blocklist != "" && c.Screening.ReloadInterval <= 0 {
+		return errors.New("screening reload interval must be positive")
+	}
+	if err := c.Generator.validate(); err != nil {
+		return err
+	}
+	// asynq stops retrying a task after 25 retries, so 26 attempts
+	if *c.Webhooks.Enabled && (c.Webhooks.MaxAttempts < 1 || c.Webhooks.MaxAttempts > 26) {
+		return errors.New("webhooks max attempts must be between 1 and 26")
+	}
+	if *c.Tracking.Stream.Enabled && c.Tracking.Stream.Heartbeat <= 0 {
+		return errors.New("tracking stream heartbeat must be positive")
+	}
+	if *c.Tracking.Stream.Enabled && c.Tracking.Stream.Buffer < 1 {
+		return errors.New("tracking stream buffer must be at least 1")
+	}
+	if c.Tracking.Retention.Archive && !(*c.Storage.Enabled) {
+		return errors.New("click archival cannot be enabled without storage")
+	}
+	if c.Tracking.Retention.KeepRestored <= 0 {
+		return errors.New("tracking retention keep restored must be positive")
+	}
+	// Partitions are created hourly, so a day ahead makes sure tomorrow's
+	// partition exists before midnight
+	if c.Tracking.Partitions.Ahead < time.Hour*24 {
+		return errors.New("tracking partitions ahead must be at least a day")
+	}
+	if c.Tracking.Rollup.Interval <= 0 {
+		return errors.New("tracking rollup interval must be positive")
+	}
+	if c.Tracking.Rollup.Delay < 0 {
+		return errors.New("tracking rollup delay cannot be negative")
+	}
+	// Rollups recount the unique ips for the days clicks are stored in, so
+	// the raw clicks for them must still exist
+	if c.Tracking.Retention.Enabled &&
+		c.Tracking.Retention.Period <= c.Tracking.Rollup.Delay+time.Hour*24 {
+		return errors.New("tracking retention period must be more than a day longer than the rollup delay")
+	}
+	if c.Expiry.FallbackUrl != "" {
+		if _, err := url.ParseRequestURI(c.Expiry.FallbackUrl); err != nil {
+			return fmt.Errorf("invalid expiry fallback url: %w", err)
+		}
+	}
 	return nil
 }
 
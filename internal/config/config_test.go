@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/henrywhitaker3/shorturl/internal/config"
 	"github.com/henrywhitaker3/shorturl/internal/crypto"
@@ -43,6 +44,161 @@ func TestItValidates(t *testing.T) {
 				require.False(t, *conf.Database.Enabled)
 			},
 		},
+		{
+			name: "it uses a custom generator alphabet",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Generator.Alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+				return toYaml(t, conf)
+			},
+			validates: true,
+			assertions: func(t *testing.T, conf *config.Config) {
+				require.Equal(t, "abcdefghjkmnpqrstuvwxyz23456789", conf.Generator.Characters())
+			},
+		},
+		{
+			name: "it defaults to a base36 generator alphabet",
+			config: func(t *testing.T) string {
+				return toYaml(t, DefaultConfig(t))
+			},
+			validates: true,
+			assertions: func(t *testing.T, conf *config.Config) {
+				require.Equal(t, config.Base36Alphabet, conf.Generator.Characters())
+			},
+		},
+		{
+			name: "it fails with a repeated character in the generator alphabet",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Generator.Alphabet = "abcdefa"
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with a geoip database and no reload interval",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Tracking.GeoIP.Database = "/etc/geoip.mmdb"
+				conf.Tracking.GeoIP.ReloadInterval = -time.Second
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with a negative stream buffer",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Tracking.Stream.Buffer = -1
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with a negative rollup delay",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Tracking.Rollup.Delay = -time.Second
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with a generator grow threshold over 1",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Generator.GrowThreshold = 1.5
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with a character that isn't url safe in the generator alphabet",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Generator.Alphabet = "abc/def"
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with the hash privacy mode without redis",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Redis.Enabled = toPtr(false)
+				conf.Queue.Enabled = toPtr(false)
+				conf.Runner.Enabled = toPtr(false)
+				conf.Tracking.Privacy.Mode = "hash"
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with a negative idempotency lock ttl",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Http.IdempotencyLockTTL = -time.Second
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with a negative keep restored period",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Tracking.Retention.KeepRestored = -time.Hour
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with a negative batch size",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Tracking.Batch.Enabled = toPtr(true)
+				conf.Tracking.Batch.Size = -1
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with a batch interval under a second",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Tracking.Batch.Enabled = toPtr(true)
+				conf.Tracking.Batch.Interval = time.Millisecond * 100
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with a negative rollup interval",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Tracking.Rollup.Interval = -time.Second
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with partitions created less than a day ahead",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Tracking.Partitions.Ahead = time.Hour
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
+		{
+			name: "it fails with auth enabled without the database",
+			config: func(t *testing.T) string {
+				conf := DefaultConfig(t)
+				conf.Database.Enabled = toPtr(false)
+				conf.Auth.Enabled = toPtr(true)
+				return toYaml(t, conf)
+			},
+			validates: false,
+		},
 	}
 
 	for _, c := range tcs {
@@ -0,0 +1,151 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+type Location struct {
+	// The ISO 3166-1 country code
+	Country string
+	Region  string
+	City    string
+}
+
+// Looks up locations from a local MaxMind (.mmdb) City or Country database.
+// When the database is missing or can't be read, lookups return an empty
+// location until a valid file appears.
+type Reader struct {
+	path string
+
+	mu       sync.RWMutex
+	db       *geoip2.Reader
+	modified time.Time
+}
+
+func New(path string) *Reader {
+	r := &Reader{path: path}
+	if _, err := r.Reload(); err != nil {
+		slog.Default().Warn("geoip database not loaded, clicks will not be located", "path", path, "error", err)
+	}
+	return r
+}
+
+// Returns the location of the ip, or an empty location when it is invalid,
+// private or not in the database
+func (r *Reader) Lookup(ip string) Location {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || !public(addr.Unmap()) {
+		return Location{}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.db == nil {
+		return Location{}
+	}
+
+	record, err := r.db.City(net.IP(addr.Unmap().AsSlice()))
+	if err != nil {
+		return Location{}
+	}
+
+	out := Location{
+		Country: record.Country.IsoCode,
+		City:    record.City.Names["en"],
+	}
+	if len(record.Subdivisions) > 0 {
+		out.Region = record.Subdivisions[0].Names["en"]
+	}
+	return out
+}
+
+func public(addr netip.Addr) bool {
+	return !(addr.IsPrivate() ||
+		addr.IsLoopback() ||
+		addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsMulticast())
+}
+
+// Reloads the database if it has changed since it was last loaded, returning
+// whether it was reloaded. The current database is kept if the file is invalid.
+func (r *Reader) Reload() (bool, error) {
+	stat, err := os.Stat(r.path)
+	if err != nil {
+		return false, fmt.Errorf("stat geoip database: %w", err)
+	}
+	r.mu.RLock()
+	unchanged := stat.ModTime().Equal(r.modified)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	db, err := geoip2.Open(r.path)
+	if err != nil {
+		return false, fmt.Errorf("open geoip database: %w", err)
+	}
+
+	r.mu.Lock()
+	old := r.db
+	r.db = db
+	r.modified = stat.ModTime()
+	r.mu.Unlock()
+
+	if old != nil {
+		if err := old.Close(); err != nil {
+			return true, fmt.Errorf("close previous geoip database: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+// Reloads the database whenever it changes. Blocking.
+func (r *Reader) Watch(ctx context.Context, interval time.Duration) {
+	logger := slog.Default().With("subsystem", "geoip")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			r.Close()
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					logger.Debug("geoip database does not exist", "path", r.path)
+					continue
+				}
+				logger.Error("could not reload geoip database", "error", err)
+				continue
+			}
+			if reloaded {
+				logger.Info("reloaded geoip database", "path", r.path)
+			}
+		}
+	}
+}
+
+func (r *Reader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.db == nil {
+		return nil
+	}
+	err := r.db.Close()
+	r.db = nil
+	return err
+}
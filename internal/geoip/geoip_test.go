@@ -0,0 +1,39 @@
+package geoip_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/henrywhitaker3/shorturl/internal/geoip"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItDegradesWithoutADatabase(t *testing.T) {
+	reader := geoip.New(filepath.Join(t.TempDir(), "missing.mmdb"))
+	defer reader.Close()
+
+	require.Equal(t, geoip.Location{}, reader.Lookup("81.2.69.160"))
+}
+
+func TestItKeepsWorkingWithAnInvalidDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.mmdb")
+	require.Nil(t, os.WriteFile(path, []byte("bongo"), 0644))
+
+	reader := geoip.New(path)
+	defer reader.Close()
+
+	reloaded, err := reader.Reload()
+	require.NotNil(t, err)
+	require.False(t, reloaded)
+	require.Equal(t, geoip.Location{}, reader.Lookup("81.2.69.160"))
+}
+
+func TestItIgnoresInvalidAndPrivateIps(t *testing.T) {
+	reader := geoip.New(filepath.Join(t.TempDir(), "missing.mmdb"))
+	defer reader.Close()
+
+	for _, ip := range []string{"", "bongo", "127.0.0.1", "10.0.0.1", "192.168.1.1", "::1", "fe80::1"} {
+		require.Equal(t, geoip.Location{}, reader.Lookup(ip), ip)
+	}
+}
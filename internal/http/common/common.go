@@ -6,12 +6,14 @@ import (
 	"strings"
 
 	"github.com/henrywhitaker3/ctxgen"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 var (
 	ctxIdKey   = "request_id"
 	traceIdKey = "trace_id"
+	keyIdKey   = "key_id"
 )
 
 func RequestID(c echo.Context) string {
@@ -34,6 +36,15 @@ func TraceID(ctx context.Context) string {
 	return ctxgen.Value[string](ctx, traceIdKey)
 }
 
+func SetKeyID(ctx context.Context, id uuid.UUID) context.Context {
+	return ctxgen.WithValue(ctx, keyIdKey, id)
+}
+
+// Returns the id of the api key that made the request, false when auth is disabled
+func KeyID(ctx context.Context) (uuid.UUID, bool) {
+	return ctxgen.ValueOk[uuid.UUID](ctx, keyIdKey)
+}
+
 func SetRequest[T any](ctx context.Context, req T) context.Context {
 	return ctxgen.WithValue(ctx, "request", req)
 }
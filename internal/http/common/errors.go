@@ -15,6 +15,10 @@ var (
 	ErrUnauth    = errors.New("unauthorised")
 	ErrForbidden = errors.New("forbidden")
 	ErrNotFound  = errors.New("not found")
+	ErrConflict  = errors.New("conflict")
+	ErrGone      = errors.New("gone")
+	ErrBusy      = errors.New("service unavailable")
+	ErrTooMany   = errors.New("too many requests")
 
 	Stack = errors.WithStack
 	Wrap  = errors.Wrap
@@ -0,0 +1,252 @@
+package urls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/logger"
+	"github.com/henrywhitaker3/shorturl/internal/queue"
+	"github.com/henrywhitaker3/shorturl/internal/screening"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxBulkUrls = 1000
+)
+
+type BulkCreateHandler struct {
+	queue    *queue.Publisher
+	alias    *urls.Alias
+	urls     urls.Urls
+	statuses *urls.Statuses
+	screener *screening.Screener
+	auth     echo.MiddlewareFunc
+	sync     bool
+}
+
+func NewBulkCreateHandler(b *boiler.Boiler) *BulkCreateHandler {
+	conf := boiler.MustResolve[*config.Config](b)
+	return &BulkCreateHandler{
+		queue:    boiler.MustResolve[*queue.Publisher](b),
+		alias:    boiler.MustResolve[*urls.Alias](b),
+		urls:     boiler.MustResolve[urls.Urls](b),
+		statuses: boiler.MustResolve[*urls.Statuses](b),
+		screener: boiler.MustResolve[*screening.Screener](b),
+		auth: middleware.Auth(
+			conf.Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+		sync: conf.Http.SyncCreate,
+	}
+}
+
+type BulkCreateRequest struct {
+	Urls []CreateRequest `json:"urls"`
+}
+
+// Only checks the size of the batch, each url is validated separately so
+// one invalid url doesn't fail the rest
+func (b BulkCreateRequest) Validate() error {
+	if len(b.Urls) == 0 {
+		return fmt.Errorf("%w urls", common.ErrRequiredField)
+	}
+	if len(b.Urls) > maxBulkUrls {
+		return fmt.Errorf("%w: at most %d urls can be created at once", common.ErrValidation, maxBulkUrls)
+	}
+	return nil
+}
+
+type BulkCreateResult struct {
+	ID *uuid.UUID `json:"id,omitempty"`
+	// The url, when it was created synchronously or already existed
+	Url   *urls.Url `json:"url,omitempty"`
+	Error string    `json:"error,omitempty"`
+}
+
+type BulkCreateResponse struct {
+	// The result for each url, in the same order as the request
+	Results []BulkCreateResult `json:"results"`
+}
+
+func (h *BulkCreateHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "BulkCreateUrls")
+		defer span.End()
+
+		req, ok := common.GetRequest[BulkCreateRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		wait, err := waitParam(c, h.sync)
+		if err != nil {
+			return err
+		}
+
+		results := make([]BulkCreateResult, len(req.Urls))
+		params := []urls.CreateParams{}
+		// The index in the results of each url being created
+		indexes := []int{}
+		// Vanity aliases claimed so far, released if the request fails
+		claimed := []string{}
+		abort := func(err error) error {
+			h.release(ctx, claimed...)
+			return err
+		}
+		for i, item := range req.Urls {
+			if err := item.Validate(); err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			dest, err := urls.NormaliseDestination(item.Url)
+			if err != nil {
+				results[i].Error = destinationError(err).Error()
+				continue
+			}
+			item.Url = dest
+			if err := h.screener.Screen(ctx, item.Url); err != nil {
+				if !errors.Is(err, screening.ErrBlocked) {
+					return abort(common.Stack(err))
+				}
+				results[i].Error = err.Error()
+				continue
+			}
+
+			if item.Reuse {
+				url, err := h.urls.GetByDestination(ctx, item.Url, owner(ctx))
+				if err == nil {
+					results[i].ID = &url.ID
+					results[i].Url = url
+					continue
+				}
+				if !errors.Is(err, sql.ErrNoRows) {
+					return abort(common.Stack(err))
+				}
+			}
+
+			if item.Alias != "" {
+				if err := h.alias.Claim(ctx, item.Alias); err != nil {
+					if errors.Is(err, urls.ErrAliasTaken) {
+						results[i].Error = err.Error()
+						continue
+					}
+					return abort(common.Stack(err))
+				}
+				claimed = append(claimed, item.Alias)
+			}
+
+			id, err := uuid.Ordered()
+			if err != nil {
+				return abort(common.Stack(err))
+			}
+			results[i].ID = &id
+			params = append(params, urls.CreateParams{
+				ID:        id,
+				Url:       item.Url,
+				Domain:    c.Request().Host,
+				Alias:     item.Alias,
+				ExpiresAt: item.ExpiresAt,
+				MaxClicks: item.MaxClicks,
+				Owner:     owner(ctx),
+			})
+			indexes = append(indexes, i)
+		}
+
+		if len(params) == 0 {
+			return c.JSON(http.StatusOK, BulkCreateResponse{Results: results})
+		}
+
+		if wait {
+			created, err := h.urls.CreateBatch(ctx, params)
+			if err == nil {
+				for i, url := range created {
+					results[indexes[i]].Url = url
+				}
+				return c.JSON(http.StatusCreated, BulkCreateResponse{Results: results})
+			}
+
+			// Create the urls one at a time so only the ones that fail are
+			// reported as errors
+			logger.Logger(ctx).Warn("could not create url batch, creating urls individually", "error", err)
+			for i, p := range params {
+				url, err := h.urls.Create(ctx, p)
+				if err != nil {
+					results[indexes[i]].ID = nil
+					results[indexes[i]].Error = createError(ctx, err)
+					if p.Alias != "" {
+						h.release(ctx, p.Alias)
+					}
+					continue
+				}
+				results[indexes[i]].Url = url
+			}
+			return c.JSON(http.StatusCreated, BulkCreateResponse{Results: results})
+		}
+
+		job := queue.CreateBatchJob{}
+		for _, p := range params {
+			if err := h.statuses.Pending(ctx, p.ID, p.Owner); err != nil {
+				return abort(common.Stack(err))
+			}
+			job.Urls = append(job.Urls, queue.CreateJob{
+				ID:        p.ID,
+				Url:       p.Url,
+				Domain:    p.Domain,
+				Alias:     p.Alias,
+				ExpiresAt: p.ExpiresAt,
+				MaxClicks: p.MaxClicks,
+				Owner:     p.Owner,
+			})
+		}
+		if err := h.queue.Push(ctx, queue.CreateBatchTask, job); err != nil {
+			return abort(common.Stack(err))
+		}
+
+		return c.JSON(http.StatusAccepted, BulkCreateResponse{Results: results})
+	}
+}
+
+// Releases claimed vanity aliases so they can be used again
+func (h *BulkCreateHandler) release(ctx context.Context, aliases ...string) {
+	for _, alias := range aliases {
+		if err := h.alias.Release(ctx, alias); err != nil {
+			logger.Logger(ctx).Error("could not release alias", "alias", alias, "error", err)
+		}
+	}
+}
+
+// Returns the error reported for a url that couldn't be created, without
+// exposing internal errors
+func createError(ctx context.Context, err error) string {
+	if errors.Is(err, urls.ErrNoFreeAlias) {
+		return urls.ErrNoFreeAlias.Error()
+	}
+	logger.Logger(ctx).Error("could not create url", "error", err)
+	return "could not create url"
+}
+
+func (h *BulkCreateHandler) Method() string {
+	return http.MethodPost
+}
+
+func (h *BulkCreateHandler) Path() string {
+	return "/urls/bulk"
+}
+
+func (h *BulkCreateHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		h.auth,
+		middleware.Bind[BulkCreateRequest](),
+	}
+}
@@ -0,0 +1,116 @@
+package urls_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/http/handlers/urls"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	iurls "github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItCreatesUrlsInBulk(t *testing.T) {
+	b := test.Boiler(t)
+	_, token := test.ApiKey(t, b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	require.Nil(t, boiler.MustResolve[*iurls.AliasGenerator](b).Run(ctx))
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls/bulk?wait=true",
+		urls.BulkCreateRequest{
+			Urls: []urls.CreateRequest{
+				{Url: "https://synthetigo.com"},
+				{Url: ""},
+				{Url: "https://example.com"},
+			},
+		},
+		token,
+	)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	resp := urls.BulkCreateResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Results, 3)
+	require.NotNil(t, resp.Results[0].Url)
+	require.Equal(t, "https://synthetigo.com", resp.Results[0].Url.Url)
+	require.Nil(t, resp.Results[1].ID)
+	require.NotEmpty(t, resp.Results[1].Error)
+	require.NotNil(t, resp.Results[2].Url)
+	require.Equal(t, "https://example.com", resp.Results[2].Url.Url)
+	require.NotEqual(t, resp.Results[0].Url.Alias, resp.Results[2].Url.Alias)
+}
+
+func TestItQueuesBulkUrls(t *testing.T) {
+	b := test.Boiler(t)
+	_, token := test.ApiKey(t, b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls/bulk",
+		urls.BulkCreateRequest{
+			Urls: []urls.CreateRequest{
+				{Url: "https://synthetigo.com"},
+				{Url: "https://example.com"},
+			},
+		},
+		token,
+	)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	resp := urls.BulkCreateResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Results, 2)
+
+	require.Nil(t, boiler.MustResolve[*iurls.AliasGenerator](b).Run(ctx))
+	test.RunQueues(t, b, ctx)
+	time.Sleep(time.Second * 2)
+
+	svc := boiler.MustResolve[iurls.Urls](b)
+	for _, res := range resp.Results {
+		require.NotNil(t, res.ID)
+		_, err := svc.Get(ctx, *res.ID)
+		require.Nil(t, err)
+	}
+}
+
+func TestItReportsBulkCreateErrorsPerUrl(t *testing.T) {
+	// A new database so there aren't any free aliases
+	b := test.Boiler(t, true)
+	_, token := test.ApiKey(t, b)
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls/bulk?wait=true",
+		urls.BulkCreateRequest{
+			Urls: []urls.CreateRequest{
+				{Url: "https://synthetigo.com", Alias: "bulk-vanity"},
+				{Url: "https://example.com"},
+			},
+		},
+		token,
+	)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	resp := urls.BulkCreateResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Results, 2)
+	require.NotNil(t, resp.Results[0].Url)
+	require.Equal(t, "bulk-vanity", resp.Results[0].Url.Alias)
+	require.Nil(t, resp.Results[1].ID)
+	require.Equal(t, iurls.ErrNoFreeAlias.Error(), resp.Results[1].Error)
+}
@@ -0,0 +1,141 @@
+package urls
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxClickBuckets = 1440
+)
+
+type ClicksHandler struct {
+	urls   urls.Urls
+	clicks *urls.Clicks
+	auth   echo.MiddlewareFunc
+}
+
+func NewClicksHandler(b *boiler.Boiler) *ClicksHandler {
+	return &ClicksHandler{
+		urls:   boiler.MustResolve[urls.Urls](b),
+		clicks: boiler.MustResolve[*urls.Clicks](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type ClicksRequest struct {
+	ID uuid.UUID `param:"id"`
+	// One of minute, hour or day, defaults to hour
+	Granularity urls.Granularity `query:"granularity"`
+	// Defaults to 24 hours before to
+	From *time.Time `query:"from"`
+	// Defaults to now
+	To *time.Time `query:"to"`
+}
+
+func (c ClicksRequest) Validate() error {
+	if c.Granularity != "" && !c.Granularity.Valid() {
+		return fmt.Errorf("%w: granularity must be minute, hour or day", common.ErrValidation)
+	}
+	if c.From != nil && c.To != nil && !c.From.Before(*c.To) {
+		return fmt.Errorf("%w: from must be before to", common.ErrValidation)
+	}
+	return nil
+}
+
+type ClicksResponse struct {
+	Granularity urls.Granularity `json:"granularity"`
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	Buckets     []*urls.Bucket   `json:"buckets"`
+}
+
+func (h *ClicksHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "GetUrlClicks")
+		defer span.End()
+
+		req, ok := common.GetRequest[ClicksRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		granularity := req.Granularity
+		if granularity == "" {
+			granularity = urls.Hour
+		}
+		to := time.Now()
+		if req.To != nil {
+			to = *req.To
+		}
+		from := to.Add(-time.Hour * 24)
+		if req.From != nil {
+			from = *req.From
+		}
+		if !from.Before(to) {
+			return fmt.Errorf("%w: from must be before to", common.ErrValidation)
+		}
+		if to.Sub(from)/granularity.Duration() > maxClickBuckets {
+			return fmt.Errorf(
+				"%w: the range can be at most %d %ss",
+				common.ErrValidation,
+				maxClickBuckets,
+				granularity,
+			)
+		}
+
+		url, err := h.urls.Get(ctx, req.ID)
+		if err != nil {
+			return common.Stack(err)
+		}
+		if err := authorise(ctx, url); err != nil {
+			return err
+		}
+
+		buckets, err := h.clicks.Series(ctx, urls.SeriesParams{
+			ID:          url.ID,
+			Granularity: granularity,
+			Since:       from,
+			Until:       to,
+		})
+		if err != nil {
+			return common.Stack(err)
+		}
+
+		return c.JSON(http.StatusOK, ClicksResponse{
+			Granularity: granularity,
+			From:        from,
+			To:          to,
+			Buckets:     buckets,
+		})
+	}
+}
+
+func (h *ClicksHandler) Method() string {
+	return http.MethodGet
+}
+
+func (h *ClicksHandler) Path() string {
+	return "/urls/:id/clicks"
+}
+
+func (h *ClicksHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		h.auth,
+		middleware.Bind[ClicksRequest](),
+	}
+}
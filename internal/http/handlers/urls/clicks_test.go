@@ -0,0 +1,76 @@
+package urls_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/http/handlers/urls"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	iurls "github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItReturnsBucketedClicks(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	url := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+
+	start := time.Now().UTC().Truncate(time.Hour).Add(-time.Hour * 3)
+	clicks := boiler.MustResolve[*iurls.Clicks](b)
+	for _, click := range []iurls.StoreClick{
+		{ID: url.ID, IP: "127.0.0.1", Time: start.Add(time.Minute)},
+		{ID: url.ID, IP: "127.0.0.1", Time: start.Add(time.Minute * 2)},
+		{ID: url.ID, IP: "127.0.0.2", Time: start.Add(time.Minute * 3)},
+		{ID: url.ID, IP: "127.0.0.1", Time: start.Add(time.Hour * 2)},
+	} {
+		require.Nil(t, clicks.Click(context.Background(), click))
+	}
+	require.Nil(t, clicks.Rollup(context.Background(), 0))
+
+	rec := test.Get(
+		t,
+		b,
+		fmt.Sprintf(
+			"/urls/%s/clicks?granularity=hour&from=%s&to=%s",
+			url.ID,
+			start.Format(time.RFC3339),
+			start.Add(time.Hour*3).Format(time.RFC3339),
+		),
+		token,
+	)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	resp := urls.ClicksResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Buckets, 3)
+	require.Equal(t, 3, resp.Buckets[0].Clicks)
+	require.Equal(t, 2, resp.Buckets[0].UniqueIPs)
+	require.Equal(t, 0, resp.Buckets[1].Clicks)
+	require.Equal(t, 1, resp.Buckets[2].Clicks)
+	require.Equal(t, 1, resp.Buckets[2].UniqueIPs)
+}
+
+func TestItLimitsTheNumberOfClickBuckets(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	url := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+
+	rec := test.Get(
+		t,
+		b,
+		fmt.Sprintf(
+			"/urls/%s/clicks?granularity=minute&from=%s",
+			url.ID,
+			time.Now().UTC().Add(-time.Hour*48).Format(time.RFC3339),
+		),
+		token,
+	)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
@@ -1,36 +1,97 @@
 package urls
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/crypto"
 	"github.com/henrywhitaker3/shorturl/internal/http/common"
 	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/idempotency"
+	"github.com/henrywhitaker3/shorturl/internal/logger"
 	"github.com/henrywhitaker3/shorturl/internal/queue"
+	"github.com/henrywhitaker3/shorturl/internal/screening"
 	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
 	"github.com/henrywhitaker3/shorturl/internal/uuid"
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	idempotencyHeader       = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+)
+
 type CreateHandler struct {
-	queue *queue.Publisher
+	queue       *queue.Publisher
+	alias       *urls.Alias
+	urls        urls.Urls
+	statuses    *urls.Statuses
+	idempotency *idempotency.Store
+	screener    *screening.Screener
+	auth        echo.MiddlewareFunc
+	sync        bool
 }
 
 func NewCreateHandler(b *boiler.Boiler) *CreateHandler {
+	conf := boiler.MustResolve[*config.Config](b)
 	return &CreateHandler{
-		queue: boiler.MustResolve[*queue.Publisher](b),
+		queue:       boiler.MustResolve[*queue.Publisher](b),
+		alias:       boiler.MustResolve[*urls.Alias](b),
+		urls:        boiler.MustResolve[urls.Urls](b),
+		statuses:    boiler.MustResolve[*urls.Statuses](b),
+		idempotency: boiler.MustResolve[*idempotency.Store](b),
+		screener:    boiler.MustResolve[*screening.Screener](b),
+		auth: middleware.Auth(
+			conf.Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+		sync: conf.Http.SyncCreate,
 	}
 }
 
 type CreateRequest struct {
-	Url string `json:"url"`
+	Url       string     `json:"url"`
+	Alias     string     `json:"alias,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxClicks *int       `json:"max_clicks,omitempty"`
+	// Return the caller's existing url for the destination if there is one
+	Reuse bool `json:"reuse,omitempty"`
 }
 
 func (c CreateRequest) Validate() error {
 	if c.Url == "" {
 		return fmt.Errorf("%w url", common.ErrRequiredField)
 	}
+	if _, err := urls.NormaliseDestination(c.Url); err != nil {
+		return destinationError(err)
+	}
+	if c.Alias != "" {
+		if err := urls.ValidateAlias(c.Alias); err != nil {
+			return fmt.Errorf("%w: %w", common.ErrValidation, err)
+		}
+	}
+	if c.ExpiresAt != nil && !c.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: expires_at must be in the future", common.ErrValidation)
+	}
+	if c.MaxClicks != nil && *c.MaxClicks < 1 {
+		return fmt.Errorf("%w: max_clicks must be at least 1", common.ErrValidation)
+	}
+	if c.Reuse && (c.Alias != "" || c.ExpiresAt != nil || c.MaxClicks != nil) {
+		return fmt.Errorf(
+			"%w: reuse cannot be used with alias, expires_at or max_clicks",
+			common.ErrValidation,
+		)
+	}
 	return nil
 }
 
@@ -48,23 +109,205 @@ func (h *CreateHandler) Handler() echo.HandlerFunc {
 			return common.ErrBadRequest
 		}
 
-		id, err := uuid.Ordered()
+		wait, err := waitParam(c, h.sync)
+		if err != nil {
+			return err
+		}
+
+		key := c.Request().Header.Get(idempotencyHeader)
+		if key == "" {
+			status, body, err := h.create(ctx, req, wait, c.Request().Host)
+			if err != nil {
+				return err
+			}
+			return c.JSON(status, body)
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return fmt.Errorf(
+				"%w: %s must be at most %d characters",
+				common.ErrValidation,
+				idempotencyHeader,
+				maxIdempotencyKeyLength,
+			)
+		}
+
+		// Keys are scoped to the api key so clients can't collide
+		scope := "public"
+		if id := owner(ctx); id != nil {
+			scope = id.String()
+		}
+		key = fmt.Sprintf("urls:%s:%s", scope, key)
+		fingerprint, err := createFingerprint(req, wait, c.Request().Host)
 		if err != nil {
 			return common.Stack(err)
 		}
 
-		if err := h.queue.Push(ctx, queue.CreateTask, queue.CreateJob{
-			ID:     id,
-			Url:    req.Url,
-			Domain: c.Request().Host,
-		}); err != nil {
+		stored, err := h.idempotency.Reserve(ctx, key, fingerprint)
+		if err != nil {
+			switch {
+			case errors.Is(err, idempotency.ErrInProgress):
+				return fmt.Errorf("%w: %w", common.ErrConflict, err)
+			case errors.Is(err, idempotency.ErrMismatch):
+				return fmt.Errorf("%w: %w", common.ErrValidation, err)
+			}
 			return common.Stack(err)
 		}
+		if stored != nil {
+			return c.JSONBlob(stored.Status, stored.Body)
+		}
+
+		status, body, err := h.create(ctx, req, wait, c.Request().Host)
+		if err != nil {
+			if rerr := h.idempotency.Release(ctx, key); rerr != nil {
+				logger.Logger(ctx).Error("could not release idempotency key", "error", rerr)
+			}
+			return err
+		}
+		if err := h.idempotency.Complete(ctx, key, fingerprint, status, body); err != nil {
+			logger.Logger(ctx).Error("could not store idempotent response", "error", err)
+		}
+		return c.JSON(status, body)
+	}
+}
 
-		return c.JSON(http.StatusAccepted, CreateResponse{
-			ID: id,
+// Creates the url, returning the status code and body of the response
+func (h *CreateHandler) create(
+	ctx context.Context,
+	req CreateRequest,
+	wait bool,
+	domain string,
+) (int, any, error) {
+	dest, err := urls.NormaliseDestination(req.Url)
+	if err != nil {
+		return 0, nil, destinationError(err)
+	}
+	req.Url = dest
+
+	if err := h.screener.Screen(ctx, req.Url); err != nil {
+		return 0, nil, screenError(err)
+	}
+
+	if req.Reuse {
+		url, err := h.urls.GetByDestination(ctx, req.Url, owner(ctx))
+		if err == nil {
+			return http.StatusOK, url, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, common.Stack(err)
+		}
+	}
+
+	id, err := uuid.Ordered()
+	if err != nil {
+		return 0, nil, common.Stack(err)
+	}
+
+	// Vanity aliases are claimed up front so we can tell the client now
+	// if it is taken, rather than failing in the queue
+	if req.Alias != "" {
+		if err := h.alias.Claim(ctx, req.Alias); err != nil {
+			if errors.Is(err, urls.ErrAliasTaken) {
+				return 0, nil, fmt.Errorf("%w: %w", common.ErrConflict, err)
+			}
+			return 0, nil, common.Stack(err)
+		}
+	}
+
+	if wait {
+		url, err := h.urls.Create(ctx, urls.CreateParams{
+			ID:        id,
+			Url:       req.Url,
+			Domain:    domain,
+			Alias:     req.Alias,
+			ExpiresAt: req.ExpiresAt,
+			MaxClicks: req.MaxClicks,
+			Owner:     owner(ctx),
 		})
+		if err != nil {
+			h.release(ctx, req.Alias)
+			if errors.Is(err, urls.ErrNoFreeAlias) {
+				return 0, nil, fmt.Errorf("%w: %w", common.ErrBusy, err)
+			}
+			return 0, nil, common.Stack(err)
+		}
+		return http.StatusCreated, url, nil
+	}
+
+	// Store the status before queueing so the worker can't update it first
+	if err := h.statuses.Pending(ctx, id, owner(ctx)); err != nil {
+		h.release(ctx, req.Alias)
+		return 0, nil, common.Stack(err)
+	}
+
+	if err := h.queue.Push(ctx, queue.CreateTask, queue.CreateJob{
+		ID:        id,
+		Url:       req.Url,
+		Domain:    domain,
+		Alias:     req.Alias,
+		ExpiresAt: req.ExpiresAt,
+		MaxClicks: req.MaxClicks,
+		Owner:     owner(ctx),
+	}); err != nil {
+		h.release(ctx, req.Alias)
+		return 0, nil, common.Stack(err)
+	}
+
+	return http.StatusAccepted, CreateResponse{
+		ID: id,
+	}, nil
+}
+
+// Releases a claimed vanity alias when the url using it couldn't be created
+func (h *CreateHandler) release(ctx context.Context, alias string) {
+	if alias == "" {
+		return
+	}
+	if err := h.alias.Release(ctx, alias); err != nil {
+		logger.Logger(ctx).Error("could not release alias", "alias", alias, "error", err)
+	}
+}
+
+// Reports an invalid destination as a validation error on the url field
+func destinationError(err error) error {
+	return fmt.Errorf("%w: url: %w", common.ErrValidation, err)
+}
+
+func screenError(err error) error {
+	if errors.Is(err, screening.ErrBlocked) {
+		return fmt.Errorf("%w: %w", common.ErrValidation, err)
+	}
+	return common.Stack(err)
+}
+
+// Whether the request asked to wait for the url to be created
+func waitParam(c echo.Context, def bool) (bool, error) {
+	q := c.QueryParam("wait")
+	if q == "" {
+		return def, nil
+	}
+	wait, err := strconv.ParseBool(q)
+	if err != nil {
+		return false, fmt.Errorf("%w: wait must be true or false", common.ErrValidation)
+	}
+	return wait, nil
+}
+
+// A hash of everything that affects the response, so an idempotency key
+// can't be reused for a different request
+func createFingerprint(req CreateRequest, wait bool, host string) (string, error) {
+	by, err := json.Marshal(struct {
+		CreateRequest
+		Wait bool   `json:"wait"`
+		Host string `json:"host"`
+	}{
+		CreateRequest: req,
+		Wait:          wait,
+		Host:          host,
+	})
+	if err != nil {
+		return "", err
 	}
+	return crypto.Sum(string(by)), nil
 }
 
 func (h *CreateHandler) Method() string {
@@ -77,6 +320,7 @@ func (h *CreateHandler) Path() string {
 
 func (h *CreateHandler) Middleware() []echo.MiddlewareFunc {
 	return []echo.MiddlewareFunc{
+		h.auth,
 		middleware.Bind[CreateRequest](),
 	}
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -54,3 +56,249 @@ func TestItCreatesAUrl(t *testing.T) {
 	require.Nil(t, err)
 	t.Log(url)
 }
+
+func TestItCreatesAUrlSynchronously(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	require.Nil(t, boiler.MustResolve[*iurls.AliasGenerator](b).Run(ctx))
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls?wait=true",
+		urls.CreateRequest{
+			Url: "https://synthetigo.com",
+		},
+		token,
+	)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	resp := iurls.Url{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Alias)
+	require.Equal(t, "https://synthetigo.com", resp.Url)
+	require.True(t, strings.HasSuffix(resp.ShortUrl, "/"+resp.Alias))
+
+	url, err := boiler.MustResolve[iurls.Urls](b).Get(ctx, resp.ID)
+	require.Nil(t, err)
+	require.Equal(t, key.ID, *url.OwnerID)
+}
+
+func TestItReturnsTheOriginalResponseForAnIdempotencyKey(t *testing.T) {
+	b := test.Boiler(t)
+	_, token := test.ApiKey(t, b)
+
+	headers := map[string]string{"Idempotency-Key": test.Letters(16)}
+	req := urls.CreateRequest{
+		Url: "https://synthetigo.com",
+	}
+
+	rec := test.Post(t, b, "/urls", req, token, headers)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	first := urls.CreateResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &first))
+
+	rec = test.Post(t, b, "/urls", req, token, headers)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	second := urls.CreateResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &second))
+	require.Equal(t, first.ID, second.ID)
+
+	rec = test.Post(
+		t,
+		b,
+		"/urls",
+		urls.CreateRequest{
+			Url: "https://example.com",
+		},
+		token,
+		headers,
+	)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestItReusesUrlsForTheSameDestination(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+	other, _ := test.ApiKey(t, b)
+
+	dest := fmt.Sprintf("https://example.com/%s", test.Letters(16))
+	test.Url(t, b, test.UrlOpts{Url: dest, Owner: &other.ID})
+	url := test.Url(t, b, test.UrlOpts{Url: dest, Owner: &key.ID})
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls",
+		urls.CreateRequest{
+			Url:   dest,
+			Reuse: true,
+		},
+		token,
+	)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := iurls.Url{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, url.ID, resp.ID)
+}
+
+func TestItRequiresAnApiKeyToCreateAUrl(t *testing.T) {
+	b := test.Boiler(t)
+	test.Auth(t, b)
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls",
+		urls.CreateRequest{
+			Url: "https://synthetigo.com",
+		},
+		"",
+	)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = test.Post(
+		t,
+		b,
+		"/urls",
+		urls.CreateRequest{
+			Url: "https://synthetigo.com",
+		},
+		"sk_bongo",
+	)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestItCreatesAUrlWithAVanityAlias(t *testing.T) {
+	b := test.Boiler(t)
+	_, token := test.ApiKey(t, b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	alias := strings.ToLower(test.Letters(12))
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls",
+		urls.CreateRequest{
+			Url:   "https://synthetigo.com",
+			Alias: alias,
+		},
+		token,
+	)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	resp := urls.CreateResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	rec = test.Post(
+		t,
+		b,
+		"/urls",
+		urls.CreateRequest{
+			Url:   "https://synthetigo.com",
+			Alias: alias,
+		},
+		token,
+	)
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	test.RunQueues(t, b, ctx)
+	time.Sleep(time.Second * 2)
+
+	url, err := boiler.MustResolve[iurls.Urls](b).Get(ctx, resp.ID)
+	require.Nil(t, err)
+	require.Equal(t, alias, url.Alias)
+}
+
+func TestItRejectsInvalidVanityAliases(t *testing.T) {
+	b := test.Boiler(t)
+	_, token := test.ApiKey(t, b)
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls",
+		urls.CreateRequest{
+			Url:   "https://synthetigo.com",
+			Alias: "urls",
+		},
+		token,
+	)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestItRejectsInvalidDestinations(t *testing.T) {
+	b := test.Boiler(t)
+	_, token := test.ApiKey(t, b)
+
+	for _, dest := range []string{"javascript:alert(1)", "/relative", "bongo"} {
+		rec := test.Post(
+			t,
+			b,
+			"/urls",
+			urls.CreateRequest{
+				Url: dest,
+			},
+			token,
+		)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code, dest)
+		require.Contains(t, rec.Body.String(), "validation error: url: ", dest)
+	}
+}
+
+func TestItRejectsBlockedDestinations(t *testing.T) {
+	b := test.Boiler(t)
+	_, token := test.ApiKey(t, b)
+
+	domain := fmt.Sprintf("%s.com", strings.ToLower(test.Letters(12)))
+	test.Blocklist(t, b, domain)
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls",
+		urls.CreateRequest{
+			Url: fmt.Sprintf("https://%s/login", domain),
+		},
+		token,
+	)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	require.Contains(t, rec.Body.String(), "destination is blocked")
+}
+
+func TestItReleasesTheAliasWhenAQueuedUrlIsBlocked(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	domain := fmt.Sprintf("%s.com", strings.ToLower(test.Letters(12)))
+	alias := strings.ToLower(test.Letters(10))
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls",
+		urls.CreateRequest{
+			Url:   fmt.Sprintf("https://%s", domain),
+			Alias: alias,
+		},
+		"",
+	)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	// Blocked after it was requested, so it fails in the queue
+	test.Blocklist(t, b, domain)
+
+	test.RunQueues(t, b, ctx)
+	time.Sleep(time.Second * 2)
+
+	require.Nil(t, boiler.MustResolve[*iurls.Alias](b).Claim(ctx, alias))
+}
@@ -0,0 +1,79 @@
+package urls
+
+import (
+	"net/http"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type DeleteHandler struct {
+	urls urls.Urls
+	auth echo.MiddlewareFunc
+}
+
+func NewDeleteHandler(b *boiler.Boiler) *DeleteHandler {
+	return &DeleteHandler{
+		urls: boiler.MustResolve[urls.Urls](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type DeleteRequest struct {
+	ID uuid.UUID `param:"id"`
+}
+
+func (d DeleteRequest) Validate() error {
+	return nil
+}
+
+func (d *DeleteHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "DeleteUrl")
+		defer span.End()
+
+		req, ok := common.GetRequest[DeleteRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		url, err := d.urls.Get(ctx, req.ID)
+		if err != nil {
+			return common.Stack(err)
+		}
+		if err := authorise(ctx, url); err != nil {
+			return err
+		}
+
+		if err := d.urls.Delete(ctx, req.ID); err != nil {
+			return common.Stack(err)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func (d *DeleteHandler) Method() string {
+	return http.MethodDelete
+}
+
+func (d *DeleteHandler) Path() string {
+	return "/urls/:id"
+}
+
+func (d *DeleteHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		d.auth,
+		middleware.Bind[DeleteRequest](),
+	}
+}
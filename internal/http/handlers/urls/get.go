@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
 	"github.com/henrywhitaker3/shorturl/internal/http/common"
 	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
 	"github.com/henrywhitaker3/shorturl/internal/tracing"
@@ -15,12 +17,17 @@ import (
 type GetHandler struct {
 	urls   urls.Urls
 	clicks *urls.Clicks
+	auth   echo.MiddlewareFunc
 }
 
 func NewGetHandler(b *boiler.Boiler) *GetHandler {
 	return &GetHandler{
 		urls:   boiler.MustResolve[urls.Urls](b),
 		clicks: boiler.MustResolve[*urls.Clicks](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
 	}
 }
 
@@ -51,6 +58,9 @@ func (g *GetHandler) Handler() echo.HandlerFunc {
 		if err != nil {
 			return common.Stack(err)
 		}
+		if err := authorise(ctx, url); err != nil {
+			return err
+		}
 
 		stats, err := g.clicks.Stats(ctx, url.ID)
 		if err != nil {
@@ -74,6 +84,7 @@ func (g *GetHandler) Path() string {
 
 func (g *GetHandler) Middleware() []echo.MiddlewareFunc {
 	return []echo.MiddlewareFunc{
+		g.auth,
 		middleware.Bind[GetRequest](),
 	}
 }
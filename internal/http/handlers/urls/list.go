@@ -0,0 +1,123 @@
+package urls
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 100
+)
+
+type ListHandler struct {
+	urls urls.Urls
+	auth echo.MiddlewareFunc
+}
+
+func NewListHandler(b *boiler.Boiler) *ListHandler {
+	return &ListHandler{
+		urls: boiler.MustResolve[urls.Urls](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type ListRequest struct {
+	Cursor        *uuid.UUID `query:"cursor"`
+	Domain        string     `query:"domain"`
+	Search        string     `query:"search"`
+	CreatedAfter  *time.Time `query:"created_after"`
+	CreatedBefore *time.Time `query:"created_before"`
+	// Either asc (oldest first) or desc (newest first)
+	Sort  string `query:"sort"`
+	Limit *int   `query:"limit"`
+}
+
+func (l ListRequest) Validate() error {
+	if l.Limit != nil && (*l.Limit < 1 || *l.Limit > maxListLimit) {
+		return fmt.Errorf("%w: limit must be between 1 and %d", common.ErrValidation, maxListLimit)
+	}
+	switch l.Sort {
+	case "", "asc", "desc":
+	default:
+		return fmt.Errorf("%w: sort must be asc or desc", common.ErrValidation)
+	}
+	if l.CreatedAfter != nil && l.CreatedBefore != nil && !l.CreatedAfter.Before(*l.CreatedBefore) {
+		return fmt.Errorf(
+			"%w: created_after must be before created_before",
+			common.ErrValidation,
+		)
+	}
+	return nil
+}
+
+type ListResponse struct {
+	Data []*urls.Url `json:"data"`
+	// The cursor to get the next page, null when there are no more results
+	NextCursor *uuid.UUID `json:"next_cursor"`
+}
+
+func (l *ListHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "ListUrls")
+		defer span.End()
+
+		req, ok := common.GetRequest[ListRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		limit := defaultListLimit
+		if req.Limit != nil {
+			limit = *req.Limit
+		}
+
+		page, err := l.urls.List(ctx, urls.ListParams{
+			Cursor:        req.Cursor,
+			Domain:        req.Domain,
+			Search:        req.Search,
+			CreatedAfter:  req.CreatedAfter,
+			CreatedBefore: req.CreatedBefore,
+			Desc:          req.Sort == "desc",
+			Limit:         limit,
+			Owner:         owner(ctx),
+		})
+		if err != nil {
+			return common.Stack(err)
+		}
+
+		return c.JSON(http.StatusOK, ListResponse{
+			Data:       page.Urls,
+			NextCursor: page.Next,
+		})
+	}
+}
+
+func (l *ListHandler) Method() string {
+	return http.MethodGet
+}
+
+func (l *ListHandler) Path() string {
+	return "/urls"
+}
+
+func (l *ListHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		l.auth,
+		middleware.Bind[ListRequest](),
+	}
+}
@@ -0,0 +1,101 @@
+package urls_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/henrywhitaker3/shorturl/internal/http/handlers/urls"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	iurls "github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItPaginatesUrls(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	domain := fmt.Sprintf("%s.com", strings.ToLower(test.Letters(10)))
+	created := []*iurls.Url{}
+	for range 3 {
+		created = append(created, test.Url(t, b, test.UrlOpts{Domain: domain, Owner: &key.ID}))
+	}
+
+	rec := test.Get(t, b, fmt.Sprintf("/urls?domain=%s&limit=2", domain), token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := urls.ListResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	require.Equal(t, created[0].ID, resp.Data[0].ID)
+	require.Equal(t, created[1].ID, resp.Data[1].ID)
+	require.NotNil(t, resp.NextCursor)
+
+	rec = test.Get(
+		t,
+		b,
+		fmt.Sprintf("/urls?domain=%s&limit=2&cursor=%s", domain, resp.NextCursor),
+		token,
+	)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp = urls.ListResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	require.Equal(t, created[2].ID, resp.Data[0].ID)
+	require.Nil(t, resp.NextCursor)
+
+	rec = test.Get(t, b, fmt.Sprintf("/urls?domain=%s&sort=desc", domain), token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp = urls.ListResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 3)
+	require.Equal(t, created[2].ID, resp.Data[0].ID)
+}
+
+func TestItSearchesUrlDestinations(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	path := strings.ToLower(test.Letters(16))
+	url := test.Url(t, b, test.UrlOpts{
+		Url:   fmt.Sprintf("https://example.com/%s", path),
+		Owner: &key.ID,
+	})
+	test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+
+	rec := test.Get(t, b, fmt.Sprintf("/urls?search=%s", strings.ToUpper(path)), token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := urls.ListResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	require.Equal(t, url.ID, resp.Data[0].ID)
+}
+
+func TestItOnlyListsUrlsOwnedByTheKey(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+	other, _ := test.ApiKey(t, b)
+
+	domain := fmt.Sprintf("%s.com", strings.ToLower(test.Letters(10)))
+	url := test.Url(t, b, test.UrlOpts{Domain: domain, Owner: &key.ID})
+	test.Url(t, b, test.UrlOpts{Domain: domain, Owner: &other.ID})
+	test.Url(t, b, test.UrlOpts{Domain: domain})
+
+	rec := test.Get(t, b, fmt.Sprintf("/urls?domain=%s", domain), token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := urls.ListResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	require.Equal(t, url.ID, resp.Data[0].ID)
+}
+
+func TestItValidatesTheListLimit(t *testing.T) {
+	b := test.Boiler(t)
+	_, token := test.ApiKey(t, b)
+
+	for _, limit := range []string{"0", "-1", "101"} {
+		rec := test.Get(t, b, fmt.Sprintf("/urls?limit=%s", limit), token)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
@@ -0,0 +1,31 @@
+package urls
+
+import (
+	"context"
+
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+)
+
+// Returns the id of the api key making the request, or nil when auth is disabled
+func owner(ctx context.Context) *uuid.UUID {
+	id, ok := common.KeyID(ctx)
+	if !ok {
+		return nil
+	}
+	return &id
+}
+
+// Checks the url was created by the api key making the request. Urls owned by
+// another key are reported as not found so their ids can't be probed.
+func authorise(ctx context.Context, url *urls.Url) error {
+	id := owner(ctx)
+	if id == nil {
+		return nil
+	}
+	if url.OwnerID == nil || *url.OwnerID != *id {
+		return common.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package urls
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type StatusHandler struct {
+	urls     urls.Urls
+	statuses *urls.Statuses
+	auth     echo.MiddlewareFunc
+}
+
+func NewStatusHandler(b *boiler.Boiler) *StatusHandler {
+	return &StatusHandler{
+		urls:     boiler.MustResolve[urls.Urls](b),
+		statuses: boiler.MustResolve[*urls.Statuses](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type StatusRequest struct {
+	ID uuid.UUID `param:"id"`
+}
+
+func (s StatusRequest) Validate() error {
+	return nil
+}
+
+type StatusResponse struct {
+	State urls.CreateState `json:"state"`
+	// Why the url could not be created
+	Reason string `json:"reason,omitempty"`
+	// The url, once it has been created
+	Url *urls.Url `json:"url,omitempty"`
+}
+
+func (s *StatusHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "GetUrlStatus")
+		defer span.End()
+
+		req, ok := common.GetRequest[StatusRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		// The url existing is the source of truth, the status may have expired
+		url, err := s.urls.Get(ctx, req.ID)
+		if err == nil {
+			if err := authorise(ctx, url); err != nil {
+				return err
+			}
+			return c.JSON(http.StatusOK, StatusResponse{
+				State: urls.StateCreated,
+				Url:   url,
+			})
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return common.Stack(err)
+		}
+
+		status, err := s.statuses.Get(ctx, req.ID)
+		if err != nil {
+			return common.Stack(err)
+		}
+		if err := authorise(ctx, &urls.Url{OwnerID: status.Owner}); err != nil {
+			return err
+		}
+		// The url was created but has since been deleted
+		if status.State == urls.StateCreated {
+			return common.ErrNotFound
+		}
+
+		return c.JSON(http.StatusOK, StatusResponse{
+			State:  status.State,
+			Reason: status.Reason,
+		})
+	}
+}
+
+func (s *StatusHandler) Method() string {
+	return http.MethodGet
+}
+
+func (s *StatusHandler) Path() string {
+	return "/urls/:id/status"
+}
+
+func (s *StatusHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		s.auth,
+		middleware.Bind[StatusRequest](),
+	}
+}
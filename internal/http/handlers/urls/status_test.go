@@ -0,0 +1,103 @@
+package urls_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/http/handlers/urls"
+	"github.com/henrywhitaker3/shorturl/internal/screening"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	iurls "github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItReportsTheCreateStatus(t *testing.T) {
+	b := test.Boiler(t)
+	_, token := test.ApiKey(t, b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	rec := test.Post(
+		t,
+		b,
+		"/urls",
+		urls.CreateRequest{
+			Url: "https://synthetigo.com",
+		},
+		token,
+	)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	created := urls.CreateResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &created))
+
+	rec = test.Get(t, b, fmt.Sprintf("/urls/%s/status", created.ID), token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := urls.StatusResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, iurls.StatePending, resp.State)
+
+	require.Nil(t, boiler.MustResolve[*iurls.AliasGenerator](b).Run(ctx))
+	test.RunQueues(t, b, ctx)
+	time.Sleep(time.Second * 2)
+
+	rec = test.Get(t, b, fmt.Sprintf("/urls/%s/status", created.ID), token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp = urls.StatusResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, iurls.StateCreated, resp.State)
+	require.NotNil(t, resp.Url)
+	require.Equal(t, "https://synthetigo.com", resp.Url.Url)
+}
+
+func TestItReportsFailedCreates(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+	_, other := test.ApiKey(t, b)
+
+	ctx := context.Background()
+	statuses := boiler.MustResolve[*iurls.Statuses](b)
+
+	id := uuid.MustOrdered()
+	require.Nil(t, statuses.Pending(ctx, id, &key.ID))
+	require.Nil(t, statuses.Failed(ctx, id, errors.New("pq: connection refused"), true))
+
+	rec := test.Get(t, b, fmt.Sprintf("/urls/%s/status", id), token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := urls.StatusResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, iurls.StateFailed, resp.State)
+	// Internal errors aren't exposed
+	require.Equal(t, "could not create url", resp.Reason)
+
+	rec = test.Get(t, b, fmt.Sprintf("/urls/%s/status", id), other)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = test.Get(t, b, fmt.Sprintf("/urls/%s/status", uuid.MustOrdered()), token)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestItReportsWhyACreateWasBlocked(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	ctx := context.Background()
+	statuses := boiler.MustResolve[*iurls.Statuses](b)
+
+	id := uuid.MustOrdered()
+	require.Nil(t, statuses.Pending(ctx, id, &key.ID))
+	require.Nil(t, statuses.Failed(ctx, id, fmt.Errorf("%w: phishing", screening.ErrBlocked), true))
+
+	rec := test.Get(t, b, fmt.Sprintf("/urls/%s/status", id), token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := urls.StatusResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "destination is blocked: phishing", resp.Reason)
+}
@@ -0,0 +1,151 @@
+package urls
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/logger"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// How long a write to a stream can block before the client is dropped
+	streamWriteTimeout = time.Second * 10
+)
+
+type ClickStreamHandler struct {
+	urls      urls.Urls
+	stream    *urls.ClickStream
+	heartbeat time.Duration
+	auth      echo.MiddlewareFunc
+}
+
+func NewClickStreamHandler(b *boiler.Boiler) *ClickStreamHandler {
+	conf := boiler.MustResolve[*config.Config](b)
+	return &ClickStreamHandler{
+		urls:      boiler.MustResolve[urls.Urls](b),
+		stream:    boiler.MustResolve[*urls.ClickStream](b),
+		heartbeat: conf.Tracking.Stream.Heartbeat,
+		auth: middleware.Auth(
+			conf.Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type ClickStreamRequest struct {
+	ID uuid.UUID `param:"id"`
+}
+
+func (c ClickStreamRequest) Validate() error {
+	return nil
+}
+
+func (h *ClickStreamHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Not traced, as the span would last as long as the stream
+		ctx := c.Request().Context()
+
+		req, ok := common.GetRequest[ClickStreamRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		url, err := h.urls.Get(ctx, req.ID)
+		if err != nil {
+			return common.Stack(err)
+		}
+		if err := authorise(ctx, url); err != nil {
+			return err
+		}
+
+		// Without auth streams are limited per client instead
+		key := fmt.Sprintf("ip:%s", c.RealIP())
+		if id := owner(ctx); id != nil {
+			key = fmt.Sprintf("key:%s", id)
+		}
+		events, stop, err := h.stream.Subscribe(ctx, key, url.ID)
+		if err != nil {
+			if errors.Is(err, urls.ErrTooManyStreams) {
+				return fmt.Errorf("%w: too many open streams", common.ErrTooMany)
+			}
+			return common.Stack(err)
+		}
+		defer stop()
+
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentType, "text/event-stream")
+		res.Header().Set(echo.HeaderCacheControl, "no-cache")
+		res.Header().Set("Connection", "keep-alive")
+		// Stop nginx from buffering the stream
+		res.Header().Set("X-Accel-Buffering", "no")
+		res.WriteHeader(http.StatusOK)
+
+		rc := http.NewResponseController(res)
+		write := func(msg string) error {
+			// Not every writer supports deadlines, the stream still works
+			// without them
+			_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if _, err := res.Write([]byte(msg)); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+		if err := write(": connected\n\n"); err != nil {
+			return nil
+		}
+
+		heartbeat := time.NewTicker(h.heartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-heartbeat.C:
+				if err := write(": ping\n\n"); err != nil {
+					return nil
+				}
+			case event, ok := <-events:
+				if !ok {
+					// The subscription failed, clients reconnect on their own
+					if ctx.Err() == nil {
+						logger.Logger(ctx).Warn("click stream subscription closed", "url", url.ID)
+					}
+					return nil
+				}
+				by, err := json.Marshal(event)
+				if err != nil {
+					return nil
+				}
+				if err := write(fmt.Sprintf("event: click\ndata: %s\n\n", by)); err != nil {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (h *ClickStreamHandler) Method() string {
+	return http.MethodGet
+}
+
+func (h *ClickStreamHandler) Path() string {
+	return "/urls/:id/clicks/stream"
+}
+
+func (h *ClickStreamHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		h.auth,
+		middleware.Bind[ClickStreamRequest](),
+	}
+}
@@ -0,0 +1,134 @@
+package urls
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultTopLimit = 10
+	maxTopLimit     = 100
+)
+
+type TopHandler struct {
+	urls        urls.Urls
+	leaderboard *urls.Leaderboard
+	auth        echo.MiddlewareFunc
+}
+
+func NewTopHandler(b *boiler.Boiler) *TopHandler {
+	return &TopHandler{
+		urls:        boiler.MustResolve[urls.Urls](b),
+		leaderboard: boiler.MustResolve[*urls.Leaderboard](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type TopRequest struct {
+	// One of 1h, 1d or 7d, defaults to 1h
+	Window urls.Window `query:"window"`
+	Limit  *int        `query:"limit"`
+}
+
+func (t TopRequest) Validate() error {
+	if t.Window != "" && !t.Window.Valid() {
+		return fmt.Errorf("%w: window must be 1h, 1d or 7d", common.ErrValidation)
+	}
+	if t.Limit != nil && (*t.Limit < 1 || *t.Limit > maxTopLimit) {
+		return fmt.Errorf("%w: limit must be between 1 and %d", common.ErrValidation, maxTopLimit)
+	}
+	return nil
+}
+
+type TopUrl struct {
+	Url    *urls.Url `json:"url"`
+	Clicks int       `json:"clicks"`
+}
+
+type TopResponse struct {
+	Window urls.Window `json:"window"`
+	Urls   []*TopUrl   `json:"urls"`
+}
+
+func (h *TopHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "GetTopUrls")
+		defer span.End()
+
+		req, ok := common.GetRequest[TopRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		window := req.Window
+		if window == "" {
+			window = urls.WindowHour
+		}
+		limit := defaultTopLimit
+		if req.Limit != nil {
+			limit = *req.Limit
+		}
+
+		// Deleted urls are skipped, so more urls are ranked until there are
+		// enough
+		out := []*TopUrl{}
+		page := limit * 2
+		for offset := 0; len(out) < limit; offset += page {
+			ranked, err := h.leaderboard.Top(ctx, owner(ctx), window, offset, page)
+			if err != nil {
+				return common.Stack(err)
+			}
+			for _, r := range ranked {
+				url, err := h.urls.Get(ctx, r.ID)
+				if err != nil {
+					// The url has been deleted since it was clicked
+					if errors.Is(err, sql.ErrNoRows) {
+						continue
+					}
+					return common.Stack(err)
+				}
+				out = append(out, &TopUrl{Url: url, Clicks: r.Clicks})
+				if len(out) == limit {
+					break
+				}
+			}
+			if len(ranked) < page {
+				break
+			}
+		}
+
+		return c.JSON(http.StatusOK, TopResponse{
+			Window: window,
+			Urls:   out,
+		})
+	}
+}
+
+func (h *TopHandler) Method() string {
+	return http.MethodGet
+}
+
+func (h *TopHandler) Path() string {
+	return "/urls/top"
+}
+
+func (h *TopHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		h.auth,
+		middleware.Bind[TopRequest](),
+	}
+}
@@ -0,0 +1,79 @@
+package urls_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/http/handlers/urls"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	iurls "github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItReturnsTheTopUrlsForTheKey(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+	otherKey, _ := test.ApiKey(t, b)
+
+	popular := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+	quiet := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+	other := test.Url(t, b, test.UrlOpts{Owner: &otherKey.ID})
+
+	top := boiler.MustResolve[*iurls.Leaderboard](b)
+	now := time.Now()
+	for _, url := range []*iurls.Url{popular, popular, quiet, other, other, other} {
+		require.Nil(t, top.Click(context.Background(), url.ID, url.OwnerID, now))
+	}
+
+	rec := test.Get(t, b, "/urls/top?window=1h&limit=50", token)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	resp := urls.TopResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, iurls.WindowHour, resp.Window)
+	require.Len(t, resp.Urls, 2)
+	require.Equal(t, popular.ID, resp.Urls[0].Url.ID)
+	require.Equal(t, 2, resp.Urls[0].Clicks)
+	require.Equal(t, quiet.ID, resp.Urls[1].Url.ID)
+	require.Equal(t, 1, resp.Urls[1].Clicks)
+}
+
+func TestItValidatesTheTopWindow(t *testing.T) {
+	b := test.Boiler(t)
+	_, token := test.ApiKey(t, b)
+
+	rec := test.Get(t, b, "/urls/top?window=2h", token)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = test.Get(t, b, "/urls/top?limit=0", token)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestItSkipsDeletedUrlsInTheTopUrls(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	deleted := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+	kept := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+
+	top := boiler.MustResolve[*iurls.Leaderboard](b)
+	now := time.Now()
+	for _, url := range []*iurls.Url{deleted, deleted, kept} {
+		require.Nil(t, top.Click(context.Background(), url.ID, url.OwnerID, now))
+	}
+	rec := test.Delete(t, b, fmt.Sprintf("/urls/%s", deleted.ID), nil, token)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = test.Get(t, b, "/urls/top?limit=1", token)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	resp := urls.TopResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Urls, 1)
+	require.Equal(t, kept.ID, resp.Urls[0].Url.ID)
+}
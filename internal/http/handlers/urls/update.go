@@ -0,0 +1,103 @@
+package urls
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/screening"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type UpdateHandler struct {
+	urls     urls.Urls
+	screener *screening.Screener
+	auth     echo.MiddlewareFunc
+}
+
+func NewUpdateHandler(b *boiler.Boiler) *UpdateHandler {
+	return &UpdateHandler{
+		urls:     boiler.MustResolve[urls.Urls](b),
+		screener: boiler.MustResolve[*screening.Screener](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type UpdateRequest struct {
+	ID  uuid.UUID `param:"id"`
+	Url string    `json:"url"`
+}
+
+func (u UpdateRequest) Validate() error {
+	if u.Url == "" {
+		return fmt.Errorf("%w url", common.ErrRequiredField)
+	}
+	if _, err := urls.NormaliseDestination(u.Url); err != nil {
+		return destinationError(err)
+	}
+	return nil
+}
+
+func (u *UpdateHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "UpdateUrl")
+		defer span.End()
+
+		req, ok := common.GetRequest[UpdateRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		existing, err := u.urls.Get(ctx, req.ID)
+		if err != nil {
+			return common.Stack(err)
+		}
+		if err := authorise(ctx, existing); err != nil {
+			return err
+		}
+
+		dest, err := urls.NormaliseDestination(req.Url)
+		if err != nil {
+			return destinationError(err)
+		}
+
+		if err := u.screener.Screen(ctx, dest); err != nil {
+			return screenError(err)
+		}
+
+		url, err := u.urls.Update(ctx, urls.UpdateParams{
+			ID:  req.ID,
+			Url: dest,
+		})
+		if err != nil {
+			return common.Stack(err)
+		}
+
+		return c.JSON(http.StatusOK, url)
+	}
+}
+
+func (u *UpdateHandler) Method() string {
+	return http.MethodPatch
+}
+
+func (u *UpdateHandler) Path() string {
+	return "/urls/:id"
+}
+
+func (u *UpdateHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		u.auth,
+		middleware.Bind[UpdateRequest](),
+	}
+}
@@ -0,0 +1,81 @@
+package urls_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/henrywhitaker3/shorturl/internal/http/handlers/urls"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItUpdatesAUrl(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	url := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+
+	// Visit it first so it is stored in the cache
+	rec := test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusPermanentRedirect, rec.Code)
+
+	rec = test.Patch(
+		t,
+		b,
+		fmt.Sprintf("/urls/%s", url.ID),
+		urls.UpdateRequest{Url: "https://synthetigo.com"},
+		token,
+	)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusPermanentRedirect, rec.Code)
+	require.Equal(t, "https://synthetigo.com", rec.Header().Get("Location"))
+}
+
+func TestItDeletesAUrl(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	url := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+
+	rec := test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusPermanentRedirect, rec.Code)
+
+	rec = test.Delete(t, b, fmt.Sprintf("/urls/%s", url.ID), nil, token)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = test.Delete(t, b, fmt.Sprintf("/urls/%s", url.ID), nil, token)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestItCannotManageUrlsOwnedByAnotherKey(t *testing.T) {
+	b := test.Boiler(t)
+	key, _ := test.ApiKey(t, b)
+	_, token := test.ApiKey(t, b)
+
+	url := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+
+	rec := test.Get(t, b, fmt.Sprintf("/urls/%s", url.ID), token)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = test.Patch(
+		t,
+		b,
+		fmt.Sprintf("/urls/%s", url.ID),
+		urls.UpdateRequest{Url: "https://synthetigo.com"},
+		token,
+	)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = test.Delete(t, b, fmt.Sprintf("/urls/%s", url.ID), nil, token)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusPermanentRedirect, rec.Code)
+	require.Equal(t, "https://example.com", rec.Header().Get("Location"))
+}
@@ -1,31 +1,56 @@
 package urls
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/henrywhitaker3/boiler"
 	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/geoip"
 	"github.com/henrywhitaker3/shorturl/internal/http/common"
 	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
 	"github.com/henrywhitaker3/shorturl/internal/logger"
+	"github.com/henrywhitaker3/shorturl/internal/privacy"
 	"github.com/henrywhitaker3/shorturl/internal/queue"
 	"github.com/henrywhitaker3/shorturl/internal/tracing"
 	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/useragent"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type VisitHandler struct {
-	urls  urls.Urls
-	queue *queue.Publisher
-	track bool
+	urls       urls.Urls
+	queue      *queue.Publisher
+	anonymiser *privacy.Anonymiser
+	geo        *geoip.Reader
+	visitors   *urls.Visitors
+	track      bool
+	dnt        bool
+	fallback   string
 }
 
 func NewVisitHandler(b *boiler.Boiler) *VisitHandler {
+	conf := boiler.MustResolve[*config.Config](b)
+	var geo *geoip.Reader
+	if conf.Tracking.GeoIP.Database != "" {
+		geo = boiler.MustResolve[*geoip.Reader](b)
+	}
+	var visitors *urls.Visitors
+	if *conf.Tracking.Visitors.Enabled {
+		visitors = boiler.MustResolve[*urls.Visitors](b)
+	}
 	return &VisitHandler{
-		urls:  boiler.MustResolve[urls.Urls](b),
-		queue: boiler.MustResolve[*queue.Publisher](b),
-		track: boiler.MustResolve[*config.Config](b).Tracking.Enabled,
+		urls:       boiler.MustResolve[urls.Urls](b),
+		queue:      boiler.MustResolve[*queue.Publisher](b),
+		anonymiser: boiler.MustResolve[*privacy.Anonymiser](b),
+		geo:        geo,
+		visitors:   visitors,
+		track:      conf.Tracking.Enabled,
+		dnt:        conf.Tracking.Privacy.HonourDoNotTrack,
+		fallback:   conf.Expiry.FallbackUrl,
 	}
 }
 
@@ -52,25 +77,103 @@ func (v *VisitHandler) Handler() echo.HandlerFunc {
 			return common.Stack(err)
 		}
 
-		if v.track {
-			if err := v.queue.Push(ctx, queue.ClickTask, queue.ClickJob{
-				ID:   url.ID,
-				IP:   c.RealIP(),
-				Time: time.Now(),
-			}); err != nil {
-				logger.Logger(ctx).Error("failed to queue click", "error", err)
+		if url.Quarantined() {
+			return quarantined(c, url)
+		}
+
+		if err := v.urls.Visit(ctx, url); err != nil {
+			if errors.Is(err, urls.ErrExpired) {
+				return v.expired(c)
+			}
+			return common.Stack(err)
+		}
+
+		if !(v.dnt && doNotTrack(c.Request())) {
+			if v.track {
+				if err := v.click(c, url); err != nil {
+					logger.Logger(ctx).Error("failed to queue click", "error", err)
+				}
+			}
+			if v.visitors != nil {
+				if err := v.visit(c, url); err != nil {
+					logger.Logger(ctx).Error("failed to count visitor", "error", err)
+				}
 			}
 		}
 
-		c.Response().
-			Header().
-			Set(echo.HeaderCacheControl, "no-cache, no-store, max-age=0, must-revalidate")
-		c.Response().Header().Set("Pragma", "no-cache")
+		noCache(c)
 
 		return c.Redirect(http.StatusPermanentRedirect, url.Url)
 	}
 }
 
+func doNotTrack(req *http.Request) bool {
+	return req.Header.Get("DNT") == "1" || req.Header.Get("Sec-GPC") == "1"
+}
+
+func (v *VisitHandler) expired(c echo.Context) error {
+	if v.fallback == "" {
+		return common.ErrGone
+	}
+	noCache(c)
+	return c.Redirect(http.StatusFound, v.fallback)
+}
+
+func noCache(c echo.Context) {
+	c.Response().
+		Header().
+		Set(echo.HeaderCacheControl, "no-cache, no-store, max-age=0, must-revalidate")
+	c.Response().Header().Set("Pragma", "no-cache")
+}
+
+// Queues the click, locating and anonymising the ip first so the raw ip is
+// never stored in the queue. The top urls are counted by the click queue, so
+// redirects don't wait on redis.
+func (v *VisitHandler) click(c echo.Context, url *urls.Url) error {
+	ctx := c.Request().Context()
+	now := time.Now()
+
+	var location geoip.Location
+	if v.geo != nil {
+		location = v.geo.Lookup(c.RealIP())
+	}
+	ip, err := v.anonymiser.Anonymise(ctx, c.RealIP(), now)
+	if err != nil {
+		return fmt.Errorf("anonymise ip: %w", err)
+	}
+
+	return v.queue.Push(ctx, queue.ClickTask, queue.ClickJob{
+		ClickID:    uuid.MustOrdered(),
+		ID:         url.ID,
+		Owner:      url.OwnerID,
+		IP:         ip,
+		Time:       now,
+		Referrer:   c.Request().Referer(),
+		UserAgent:  c.Request().UserAgent(),
+		Language:   c.Request().Header.Get("Accept-Language"),
+		Country:    location.Country,
+		Region:     location.Region,
+		City:       location.City,
+		Anonymised: true,
+	})
+}
+
+// Counts the visitor for the url's unique visitors. This doesn't go through
+// the click queue, so the counts don't depend on click tracking.
+func (v *VisitHandler) visit(c echo.Context, url *urls.Url) error {
+	if useragent.Parse(c.Request().UserAgent()).Bot {
+		return nil
+	}
+	ctx := c.Request().Context()
+	now := time.Now()
+
+	visitor, err := v.anonymiser.Fingerprint(ctx, c.RealIP(), c.Request().UserAgent(), now)
+	if err != nil {
+		return fmt.Errorf("fingerprint visitor: %w", err)
+	}
+	return v.visitors.Add(ctx, url.ID, visitor, now)
+}
+
 func (v *VisitHandler) Method() string {
 	return http.MethodGet
 }
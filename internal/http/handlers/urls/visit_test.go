@@ -0,0 +1,94 @@
+package urls_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	iurls "github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItRedirectsToTheUrl(t *testing.T) {
+	b := test.Boiler(t)
+
+	url := test.Url(t, b, test.UrlOpts{})
+
+	rec := test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusPermanentRedirect, rec.Code)
+	require.Equal(t, url.Url, rec.Header().Get("Location"))
+}
+
+func TestItReturnsGoneAfterMaxClicks(t *testing.T) {
+	b := test.Boiler(t)
+
+	max := 2
+	url := test.Url(t, b, test.UrlOpts{
+		MaxClicks: &max,
+	})
+
+	for range max {
+		rec := test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+		require.Equal(t, http.StatusPermanentRedirect, rec.Code)
+	}
+
+	rec := test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusGone, rec.Code)
+}
+
+func TestItReturnsGoneAfterExpiry(t *testing.T) {
+	b := test.Boiler(t)
+
+	expires := time.Now().Add(time.Second * 2)
+	url := test.Url(t, b, test.UrlOpts{
+		ExpiresAt: &expires,
+	})
+
+	rec := test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusPermanentRedirect, rec.Code)
+
+	time.Sleep(time.Until(expires) + time.Second)
+
+	rec = test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusGone, rec.Code)
+}
+
+func TestItServesAWarningForQuarantinedUrls(t *testing.T) {
+	b := test.Boiler(t)
+
+	url := test.Url(t, b, test.UrlOpts{})
+
+	rec := test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusPermanentRedirect, rec.Code)
+
+	_, err := boiler.MustResolve[iurls.Urls](b).Quarantine(context.Background(), url.ID, "bongo")
+	require.Nil(t, err)
+
+	rec = test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.Empty(t, rec.Header().Get("Location"))
+	require.Contains(t, rec.Body.String(), "This link has been disabled")
+	require.NotContains(t, rec.Body.String(), url.Url)
+}
+
+func TestItCountsVisitorsWithoutClickTracking(t *testing.T) {
+	b := test.Boiler(t)
+
+	url := test.Url(t, b, test.UrlOpts{})
+
+	for _, agent := range []string{"Mozilla/5.0 Firefox/131.0", "Mozilla/5.0 Firefox/131.0", "Googlebot/2.1"} {
+		rec := test.Get(t, b, fmt.Sprintf("/%s", url.Alias), "", map[string]string{
+			"User-Agent": agent,
+		})
+		require.Equal(t, http.StatusPermanentRedirect, rec.Code)
+	}
+
+	now := time.Now()
+	count, err := boiler.MustResolve[*iurls.Visitors](b).Count(context.Background(), url.ID, now, now)
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+}
@@ -0,0 +1,123 @@
+package urls
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type VisitorsHandler struct {
+	urls     urls.Urls
+	visitors *urls.Visitors
+	auth     echo.MiddlewareFunc
+}
+
+func NewVisitorsHandler(b *boiler.Boiler) *VisitorsHandler {
+	return &VisitorsHandler{
+		urls:     boiler.MustResolve[urls.Urls](b),
+		visitors: boiler.MustResolve[*urls.Visitors](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type VisitorsRequest struct {
+	ID uuid.UUID `param:"id"`
+	// Defaults to 29 days before to
+	From *time.Time `query:"from"`
+	// Defaults to now
+	To *time.Time `query:"to"`
+}
+
+func (v VisitorsRequest) Validate() error {
+	if v.From != nil && v.To != nil && v.To.Before(*v.From) {
+		return fmt.Errorf("%w: from must not be after to", common.ErrValidation)
+	}
+	return nil
+}
+
+type VisitorsResponse struct {
+	// The first day counted
+	From time.Time `json:"from"`
+	// The last day counted
+	To             time.Time `json:"to"`
+	UniqueVisitors int       `json:"unique_visitors"`
+}
+
+func (h *VisitorsHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "GetUrlVisitors")
+		defer span.End()
+
+		req, ok := common.GetRequest[VisitorsRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		day := time.Hour * 24
+		to := time.Now().UTC().Truncate(day)
+		if req.To != nil {
+			to = req.To.UTC().Truncate(day)
+		}
+		from := to.Add(-day * 29)
+		if req.From != nil {
+			from = req.From.UTC().Truncate(day)
+		}
+		if to.Before(from) {
+			return fmt.Errorf("%w: from must not be after to", common.ErrValidation)
+		}
+		if to.Sub(from)/day >= urls.MaxVisitorDays {
+			return fmt.Errorf(
+				"%w: the range can be at most %d days",
+				common.ErrValidation,
+				urls.MaxVisitorDays,
+			)
+		}
+
+		url, err := h.urls.Get(ctx, req.ID)
+		if err != nil {
+			return common.Stack(err)
+		}
+		if err := authorise(ctx, url); err != nil {
+			return err
+		}
+
+		count, err := h.visitors.Count(ctx, url.ID, from, to)
+		if err != nil {
+			return common.Stack(err)
+		}
+
+		return c.JSON(http.StatusOK, VisitorsResponse{
+			From:           from,
+			To:             to,
+			UniqueVisitors: count,
+		})
+	}
+}
+
+func (h *VisitorsHandler) Method() string {
+	return http.MethodGet
+}
+
+func (h *VisitorsHandler) Path() string {
+	return "/urls/:id/visitors"
+}
+
+func (h *VisitorsHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		h.auth,
+		middleware.Bind[VisitorsRequest](),
+	}
+}
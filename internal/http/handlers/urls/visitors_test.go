@@ -0,0 +1,56 @@
+package urls_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/http/handlers/urls"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	iurls "github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItReturnsUniqueVisitors(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	url := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+
+	visitors := boiler.MustResolve[*iurls.Visitors](b)
+	now := time.Now()
+	for _, visitor := range []string{"alice", "bob", "alice"} {
+		require.Nil(t, visitors.Add(context.Background(), url.ID, visitor, now))
+	}
+
+	rec := test.Get(t, b, fmt.Sprintf("/urls/%s/visitors", url.ID), token)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	resp := urls.VisitorsResponse{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, 2, resp.UniqueVisitors)
+	require.Equal(t, now.UTC().Truncate(time.Hour*24), resp.To)
+}
+
+func TestItLimitsTheVisitorsRange(t *testing.T) {
+	b := test.Boiler(t)
+	key, token := test.ApiKey(t, b)
+
+	url := test.Url(t, b, test.UrlOpts{Owner: &key.ID})
+
+	rec := test.Get(
+		t,
+		b,
+		fmt.Sprintf(
+			"/urls/%s/visitors?from=%s",
+			url.ID,
+			time.Now().UTC().Add(-time.Hour*24*400).Format(time.RFC3339),
+		),
+		token,
+	)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
@@ -0,0 +1,37 @@
+package urls
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	warningPage = template.Must(template.New("warning").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="robots" content="noindex">
+  <title>Link disabled</title>
+</head>
+<body>
+  <h1>This link has been disabled</h1>
+  <p>The link {{ .ShortUrl }} was disabled because its destination was flagged as potentially harmful.</p>
+</body>
+</html>
+`))
+)
+
+// Serves a page explaining the url has been quarantined, without linking
+// to the destination
+func quarantined(c echo.Context, url *urls.Url) error {
+	noCache(c)
+	out := &strings.Builder{}
+	if err := warningPage.Execute(out, url); err != nil {
+		return err
+	}
+	return c.HTML(http.StatusForbidden, out.String())
+}
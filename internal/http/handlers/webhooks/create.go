@@ -0,0 +1,104 @@
+package webhooks
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/webhooks"
+	"github.com/labstack/echo/v4"
+)
+
+type CreateHandler struct {
+	webhooks     *webhooks.Webhooks
+	allowPrivate bool
+	auth         echo.MiddlewareFunc
+}
+
+func NewCreateHandler(b *boiler.Boiler) *CreateHandler {
+	conf := boiler.MustResolve[*config.Config](b)
+	return &CreateHandler{
+		webhooks:     boiler.MustResolve[*webhooks.Webhooks](b),
+		allowPrivate: conf.Webhooks.AllowPrivate,
+		auth: middleware.Auth(
+			conf.Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type CreateRequest struct {
+	Url    string               `json:"url"`
+	Events []webhooks.EventType `json:"events"`
+}
+
+func (c CreateRequest) Validate() error {
+	if c.Url == "" {
+		return fmt.Errorf("%w url", common.ErrRequiredField)
+	}
+	u, err := url.Parse(c.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https url", common.ErrValidation)
+	}
+	if len(c.Events) == 0 {
+		return fmt.Errorf("%w events", common.ErrRequiredField)
+	}
+	for _, event := range c.Events {
+		if !event.Valid() {
+			return fmt.Errorf("%w: unknown event %q", common.ErrValidation, event)
+		}
+	}
+	return nil
+}
+
+func (h *CreateHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "CreateWebhook")
+		defer span.End()
+
+		req, ok := common.GetRequest[CreateRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		// Deliveries check the address again when they connect, in case the
+		// host resolves somewhere else later
+		if !h.allowPrivate {
+			if err := webhooks.CheckUrl(ctx, req.Url); err != nil {
+				return fmt.Errorf("%w: url: %w", common.ErrValidation, err)
+			}
+		}
+
+		webhook, err := h.webhooks.Create(ctx, webhooks.CreateParams{
+			Url:    req.Url,
+			Events: req.Events,
+			Owner:  owner(ctx),
+		})
+		if err != nil {
+			return common.Stack(err)
+		}
+
+		return c.JSON(http.StatusCreated, webhook)
+	}
+}
+
+func (h *CreateHandler) Method() string {
+	return http.MethodPost
+}
+
+func (h *CreateHandler) Path() string {
+	return "/webhooks"
+}
+
+func (h *CreateHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		h.auth,
+		middleware.Bind[CreateRequest](),
+	}
+}
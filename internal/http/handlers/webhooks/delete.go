@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/henrywhitaker3/shorturl/internal/webhooks"
+	"github.com/labstack/echo/v4"
+)
+
+type DeleteHandler struct {
+	webhooks *webhooks.Webhooks
+	auth     echo.MiddlewareFunc
+}
+
+func NewDeleteHandler(b *boiler.Boiler) *DeleteHandler {
+	return &DeleteHandler{
+		webhooks: boiler.MustResolve[*webhooks.Webhooks](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type DeleteRequest struct {
+	ID uuid.UUID `param:"id"`
+}
+
+func (d DeleteRequest) Validate() error {
+	return nil
+}
+
+func (d *DeleteHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "DeleteWebhook")
+		defer span.End()
+
+		req, ok := common.GetRequest[DeleteRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		if err := d.webhooks.Delete(ctx, req.ID, owner(ctx)); err != nil {
+			return common.Stack(err)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func (d *DeleteHandler) Method() string {
+	return http.MethodDelete
+}
+
+func (d *DeleteHandler) Path() string {
+	return "/webhooks/:id"
+}
+
+func (d *DeleteHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		d.auth,
+		middleware.Bind[DeleteRequest](),
+	}
+}
@@ -0,0 +1,106 @@
+package webhooks
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/henrywhitaker3/shorturl/internal/webhooks"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 100
+)
+
+type DeliveriesHandler struct {
+	webhooks *webhooks.Webhooks
+	auth     echo.MiddlewareFunc
+}
+
+func NewDeliveriesHandler(b *boiler.Boiler) *DeliveriesHandler {
+	return &DeliveriesHandler{
+		webhooks: boiler.MustResolve[*webhooks.Webhooks](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type DeliveriesRequest struct {
+	ID     uuid.UUID  `param:"id"`
+	Cursor *uuid.UUID `query:"cursor"`
+	Limit  int        `query:"limit"`
+}
+
+func (d DeliveriesRequest) Validate() error {
+	if d.Limit < 0 || d.Limit > maxDeliveriesLimit {
+		return fmt.Errorf(
+			"%w: limit must be between 1 and %d",
+			common.ErrValidation,
+			maxDeliveriesLimit,
+		)
+	}
+	return nil
+}
+
+type DeliveriesResponse struct {
+	Data []*webhooks.Delivery `json:"data"`
+	// The cursor to get the next page, null when there are no more results
+	NextCursor *uuid.UUID `json:"next_cursor"`
+}
+
+func (d *DeliveriesHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "ListWebhookDeliveries")
+		defer span.End()
+
+		req, ok := common.GetRequest[DeliveriesRequest](ctx)
+		if !ok {
+			return common.ErrBadRequest
+		}
+
+		// Checks the webhook belongs to the caller
+		if _, err := d.webhooks.Get(ctx, req.ID, owner(ctx)); err != nil {
+			return common.Stack(err)
+		}
+
+		limit := req.Limit
+		if limit == 0 {
+			limit = defaultDeliveriesLimit
+		}
+
+		page, err := d.webhooks.Deliveries(ctx, req.ID, req.Cursor, limit)
+		if err != nil {
+			return common.Stack(err)
+		}
+
+		return c.JSON(http.StatusOK, DeliveriesResponse{
+			Data:       page.Deliveries,
+			NextCursor: page.Next,
+		})
+	}
+}
+
+func (d *DeliveriesHandler) Method() string {
+	return http.MethodGet
+}
+
+func (d *DeliveriesHandler) Path() string {
+	return "/webhooks/:id/deliveries"
+}
+
+func (d *DeliveriesHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		d.auth,
+		middleware.Bind[DeliveriesRequest](),
+	}
+}
@@ -0,0 +1,61 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/henrywhitaker3/shorturl/internal/webhooks"
+	"github.com/labstack/echo/v4"
+)
+
+type ListHandler struct {
+	webhooks *webhooks.Webhooks
+	auth     echo.MiddlewareFunc
+}
+
+func NewListHandler(b *boiler.Boiler) *ListHandler {
+	return &ListHandler{
+		webhooks: boiler.MustResolve[*webhooks.Webhooks](b),
+		auth: middleware.Auth(
+			boiler.MustResolve[*config.Config](b).Auth,
+			boiler.MustResolve[*apikeys.Keys](b),
+		),
+	}
+}
+
+type ListResponse struct {
+	Data []*webhooks.Webhook `json:"data"`
+}
+
+func (l *ListHandler) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracing.NewSpan(c.Request().Context(), "ListWebhooks")
+		defer span.End()
+
+		hooks, err := l.webhooks.List(ctx, owner(ctx))
+		if err != nil {
+			return common.Stack(err)
+		}
+
+		return c.JSON(http.StatusOK, ListResponse{Data: hooks})
+	}
+}
+
+func (l *ListHandler) Method() string {
+	return http.MethodGet
+}
+
+func (l *ListHandler) Path() string {
+	return "/webhooks"
+}
+
+func (l *ListHandler) Middleware() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		l.auth,
+	}
+}
@@ -0,0 +1,17 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+)
+
+// Returns the id of the api key making the request, or nil when auth is disabled
+func owner(ctx context.Context) *uuid.UUID {
+	id, ok := common.KeyID(ctx)
+	if !ok {
+		return nil
+	}
+	return &id
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/henrywhitaker3/shorturl/internal/config"
 	"github.com/henrywhitaker3/shorturl/internal/http/common"
 	"github.com/henrywhitaker3/shorturl/internal/http/handlers/urls"
+	"github.com/henrywhitaker3/shorturl/internal/http/handlers/webhooks"
 	"github.com/henrywhitaker3/shorturl/internal/http/middleware"
 	"github.com/henrywhitaker3/shorturl/internal/logger"
 	"github.com/henrywhitaker3/shorturl/internal/metrics"
@@ -60,9 +61,23 @@ func New(b *boiler.Boiler) *Http {
 	h.e.HTTPErrorHandler = h.handleError
 
 	h.Register(urls.NewCreateHandler(b))
+	h.Register(urls.NewBulkCreateHandler(b))
 	h.Register(urls.NewGetHandler(b))
+	h.Register(urls.NewStatusHandler(b))
+	h.Register(urls.NewClicksHandler(b))
+	h.Register(urls.NewClickStreamHandler(b))
+	h.Register(urls.NewVisitorsHandler(b))
+	h.Register(urls.NewTopHandler(b))
+	h.Register(urls.NewListHandler(b))
+	h.Register(urls.NewUpdateHandler(b))
+	h.Register(urls.NewDeleteHandler(b))
 	h.Register(urls.NewVisitHandler(b))
 
+	h.Register(webhooks.NewCreateHandler(b))
+	h.Register(webhooks.NewListHandler(b))
+	h.Register(webhooks.NewDeleteHandler(b))
+	h.Register(webhooks.NewDeliveriesHandler(b))
+
 	return h
 }
 
@@ -157,6 +172,18 @@ func (h *Http) handleError(err error, c echo.Context) {
 	case errors.Is(err, common.ErrNotFound):
 		c.JSON(http.StatusNotFound, newError("not found"))
 
+	case errors.Is(err, common.ErrConflict):
+		c.JSON(http.StatusConflict, newError(err.Error()))
+
+	case errors.Is(err, common.ErrGone):
+		c.JSON(http.StatusGone, newError("gone"))
+
+	case errors.Is(err, common.ErrBusy):
+		c.JSON(http.StatusServiceUnavailable, newError(err.Error()))
+
+	case errors.Is(err, common.ErrTooMany):
+		c.JSON(http.StatusTooManyRequests, newError(err.Error()))
+
 	case h.isHttpError(err):
 		herr := err.(*echo.HTTPError)
 		c.JSON(herr.Code, herr)
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/http/common"
+	"github.com/henrywhitaker3/shorturl/internal/tracing"
+	"github.com/labstack/echo/v4"
+)
+
+func Auth(conf config.Auth, keys *apikeys.Keys) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !*conf.Enabled {
+				return next(c)
+			}
+
+			ctx, span := tracing.NewSpan(c.Request().Context(), "Authenticate")
+			defer span.End()
+
+			token := common.GetToken(c.Request())
+			if token == "" {
+				return common.ErrUnauth
+			}
+			key, err := keys.Verify(ctx, token)
+			if err != nil {
+				if errors.Is(err, apikeys.ErrInvalidKey) {
+					return common.ErrUnauth
+				}
+				return common.Stack(err)
+			}
+
+			ctx = common.SetKeyID(c.Request().Context(), key.ID)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+var (
+	ErrInProgress = errors.New("a request with the same idempotency key is in progress")
+	ErrMismatch   = errors.New("idempotency key was used for a different request")
+)
+
+// The response sent for the original request
+type Response struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+type record struct {
+	Fingerprint string `json:"fingerprint"`
+	// Nil until the original request has finished
+	Response *Response `json:"response,omitempty"`
+}
+
+// Stores the responses for requests by their idempotency key, so a retried
+// request gets the original response instead of being handled again
+type Store struct {
+	redis   rueidis.Client
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+type StoreOpts struct {
+	Redis rueidis.Client
+	// How long the keys are remembered for
+	TTL time.Duration
+	// How long a key is reserved for while its request is in progress, so
+	// it can be retried if the request never completes or releases it
+	LockTTL time.Duration
+}
+
+func New(opts StoreOpts) *Store {
+	return &Store{
+		redis:   opts.Redis,
+		ttl:     opts.TTL,
+		lockTTL: opts.LockTTL,
+	}
+}
+
+// Reserves the key for the request with the given fingerprint. Returns the
+// original response if the key has already been used for the same request,
+// otherwise nil and the caller must either Complete or Release the key
+// before the lock ttl expires.
+func (s *Store) Reserve(ctx context.Context, key, fingerprint string) (*Response, error) {
+	by, err := json.Marshal(record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, fmt.Errorf("marshal idempotency record: %w", err)
+	}
+
+	cmd := s.redis.B().Set().Key(redisKey(key)).Value(string(by)).Nx().Ex(s.lockTTL).Build()
+	err = s.redis.Do(ctx, cmd).Error()
+	if err == nil {
+		return nil, nil
+	}
+	if !rueidis.IsRedisNil(err) {
+		return nil, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+
+	// The key has already been reserved
+	by, err = s.redis.Do(ctx, s.redis.B().Get().Key(redisKey(key)).Build()).AsBytes()
+	if err != nil {
+		if rueidis.IsRedisNil(err) {
+			// It expired since we tried to reserve it
+			return s.Reserve(ctx, key, fingerprint)
+		}
+		return nil, fmt.Errorf("get idempotency key: %w", err)
+	}
+	rec := record{}
+	if err := json.Unmarshal(by, &rec); err != nil {
+		return nil, fmt.Errorf("unmarshal idempotency record: %w", err)
+	}
+	if rec.Fingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+	if rec.Response == nil {
+		return nil, ErrInProgress
+	}
+	return rec.Response, nil
+}
+
+// Stores the response for the reserved key, keeping it for the full ttl
+func (s *Store) Complete(
+	ctx context.Context,
+	key, fingerprint string,
+	status int,
+	body any,
+) error {
+	resp, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal idempotent response: %w", err)
+	}
+	by, err := json.Marshal(record{
+		Fingerprint: fingerprint,
+		Response: &Response{
+			Status: status,
+			Body:   resp,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal idempotency record: %w", err)
+	}
+
+	cmd := s.redis.B().Set().Key(redisKey(key)).Value(string(by)).Ex(s.ttl).Build()
+	if err := s.redis.Do(ctx, cmd).Error(); err != nil {
+		return fmt.Errorf("store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Releases the reserved key so the request can be retried
+func (s *Store) Release(ctx context.Context, key string) error {
+	if err := s.redis.Do(ctx, s.redis.B().Del().Key(redisKey(key)).Build()).Error(); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func redisKey(key string) string {
+	return fmt.Sprintf("idempotency:%s", key)
+}
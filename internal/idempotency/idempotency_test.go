@@ -0,0 +1,53 @@
+package idempotency_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/idempotency"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItExpiresTheLockForUnfinishedRequests(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	store := idempotency.New(idempotency.StoreOpts{
+		Redis:   boiler.MustResolve[rueidis.Client](b),
+		TTL:     time.Hour,
+		LockTTL: time.Second,
+	})
+	key := test.Letters(12)
+
+	resp, err := store.Reserve(ctx, key, "bongo")
+	require.Nil(t, err)
+	require.Nil(t, resp)
+
+	_, err = store.Reserve(ctx, key, "bongo")
+	require.ErrorIs(t, err, idempotency.ErrInProgress)
+
+	// The request never completed or released the key
+	time.Sleep(time.Second * 2)
+
+	resp, err = store.Reserve(ctx, key, "bongo")
+	require.Nil(t, err)
+	require.Nil(t, resp)
+
+	require.Nil(t, store.Complete(ctx, key, "bongo", http.StatusCreated, map[string]string{"id": "bongo"}))
+
+	// Completed responses are kept for the full ttl
+	time.Sleep(time.Second * 2)
+
+	resp, err = store.Reserve(ctx, key, "bongo")
+	require.Nil(t, err)
+	require.NotNil(t, resp)
+	require.Equal(t, http.StatusCreated, resp.Status)
+	require.JSONEq(t, `{"id":"bongo"}`, string(resp.Body))
+}
@@ -55,6 +55,19 @@ func (l *LRU[T, U]) Add(key T, val U) bool {
 	return evicted
 }
 
+func (l *LRU[T, U]) Remove(key T) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := false
+	for _, c := range l.pool {
+		if c.Remove(key) {
+			removed = true
+		}
+	}
+	return removed
+}
+
 func (l *LRU[T, U]) Len() int {
 	return l.read().Len()
 }
@@ -55,6 +55,34 @@ var (
 		Help: "The length of time taken for a task to be processed in seconds",
 	}, []string{"task"})
 
+	ClickBatchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "click_batch_size",
+		Help:    "The number of clicks stored in each batch",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	})
+	ClickBatchFlushDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "click_batch_flush_duration_seconds",
+		Help: "The length of time taken to store a batch of clicks in seconds",
+	})
+	ClickBatchDuplicates = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "click_batch_duplicates_total",
+		Help: "The number of clicks skipped in a batch as they were already stored",
+	})
+
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_delivery_attempts_total",
+		Help: "The number of webhook delivery attempts by the resulting status",
+	}, []string{"status"})
+
+	ClickStreams = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "click_streams",
+		Help: "The number of live click streams open",
+	})
+	ClickStreamEventsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "click_stream_events_dropped_total",
+		Help: "The number of click events dropped as a stream couldn't keep up",
+	})
+
 	ApiMetrics = []prometheus.Collector{
 		WorkerExecutions,
 		WorkerExecutionErrors,
@@ -62,12 +90,18 @@ var (
 		Registrations,
 		QueueTasksPushed,
 		QueueTasksPushFailures,
+		ClickStreams,
+		ClickStreamEventsDropped,
 	}
 
 	QueueConsumerMetrics = []prometheus.Collector{
 		QueueTasksProcessedDuration,
 		QueueTasksProcessed,
 		QueueTasksProcessedErrors,
+		ClickBatchSize,
+		ClickBatchFlushDuration,
+		ClickBatchDuplicates,
+		WebhookDeliveries,
 	}
 )
 
@@ -0,0 +1,152 @@
+package privacy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+type Mode string
+
+const (
+	// Store ips as they are
+	Full Mode = "full"
+	// Store the /24 for ipv4 and /48 for ipv6 addresses
+	Truncate Mode = "truncate"
+	// Store a keyed hash of the ip, using a salt that changes every day
+	Hash Mode = "hash"
+)
+
+// Salts are kept for a little over a day so clicks queued just before
+// midnight can still be hashed with the salt for the day they happened
+const saltTTL = time.Hour * 48
+
+// Anonymises visitor ips before they are stored
+type Anonymiser struct {
+	mode  Mode
+	redis rueidis.Client
+
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+type AnonymiserOpts struct {
+	Mode Mode
+	// Used to share the daily salt between replicas, only required in
+	// hash mode or for fingerprints
+	Redis rueidis.Client
+}
+
+func New(opts AnonymiserOpts) *Anonymiser {
+	return &Anonymiser{
+		mode:  opts.Mode,
+		redis: opts.Redis,
+	}
+}
+
+// Returns the ip to store for a click at the given time. Invalid ips are
+// returned as an empty string unless the mode is full.
+func (a *Anonymiser) Anonymise(ctx context.Context, ip string, at time.Time) (string, error) {
+	if a.mode == Full || a.mode == "" {
+		return ip, nil
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", nil
+	}
+	addr = addr.Unmap()
+
+	switch a.mode {
+	case Truncate:
+		return truncate(addr), nil
+	case Hash:
+		salt, err := a.saltFor(ctx, at)
+		if err != nil {
+			return "", err
+		}
+		mac := hmac.New(sha256.New, salt)
+		mac.Write(addr.AsSlice())
+		return hex.EncodeToString(mac.Sum(nil)), nil
+	default:
+		return "", fmt.Errorf("unknown privacy mode %s", a.mode)
+	}
+}
+
+// Identifies a visitor by their ip and user agent without storing the ip.
+// It is keyed with the daily salt in every mode, so it can't be brute-forced
+// back to an ip and only identifies the visitor within a day.
+func (a *Anonymiser) Fingerprint(ctx context.Context, ip, userAgent string, at time.Time) (string, error) {
+	salt, err := a.saltFor(ctx, at)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(ip))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+func truncate(addr netip.Addr) string {
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.Addr().String()
+}
+
+// Gets the salt for the day, generating it if no replica has yet. Old salts
+// expire from redis so past hashes can't be linked back to an ip.
+func (a *Anonymiser) saltFor(ctx context.Context, at time.Time) ([]byte, error) {
+	day := at.UTC().Format(time.DateOnly)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.day == day {
+		return a.salt, nil
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+	key := fmt.Sprintf("privacy:salt:%s", day)
+	cmd := a.redis.B().Set().Key(key).Value(hex.EncodeToString(salt)).Nx().Ex(saltTTL).Build()
+	err := a.redis.Do(ctx, cmd).Error()
+	if err != nil {
+		if !rueidis.IsRedisNil(err) {
+			return nil, fmt.Errorf("store salt: %w", err)
+		}
+		// Another replica has already generated the salt for the day
+		existing, err := a.redis.Do(ctx, a.redis.B().Get().Key(key).Build()).ToString()
+		if err != nil {
+			return nil, fmt.Errorf("get salt: %w", err)
+		}
+		salt, err = hex.DecodeString(existing)
+		if err != nil {
+			return nil, fmt.Errorf("decode salt: %w", err)
+		}
+	}
+
+	// Only cache the salt for today, older clicks are rare enough to
+	// fetch it each time
+	if day == time.Now().UTC().Format(time.DateOnly) {
+		a.day = day
+		a.salt = salt
+	}
+	return salt, nil
+}
@@ -0,0 +1,103 @@
+package privacy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/privacy"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItTruncatesIps(t *testing.T) {
+	anon := privacy.New(privacy.AnonymiserOpts{Mode: privacy.Truncate})
+
+	tcs := []struct {
+		ip       string
+		expected string
+	}{
+		{ip: "81.2.69.160", expected: "81.2.69.0"},
+		{ip: "::ffff:81.2.69.160", expected: "81.2.69.0"},
+		{ip: "2001:db8:85a3:8d3:1319:8a2e:370:7348", expected: "2001:db8:85a3::"},
+		{ip: "bongo", expected: ""},
+	}
+
+	for _, c := range tcs {
+		t.Run(c.ip, func(t *testing.T) {
+			ip, err := anon.Anonymise(context.Background(), c.ip, time.Now())
+			require.Nil(t, err)
+			require.Equal(t, c.expected, ip)
+		})
+	}
+}
+
+func TestItStoresFullIps(t *testing.T) {
+	anon := privacy.New(privacy.AnonymiserOpts{Mode: privacy.Full})
+
+	ip, err := anon.Anonymise(context.Background(), "81.2.69.160", time.Now())
+	require.Nil(t, err)
+	require.Equal(t, "81.2.69.160", ip)
+}
+
+func TestItHashesIpsWithADailySalt(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	opts := privacy.AnonymiserOpts{
+		Mode:  privacy.Hash,
+		Redis: boiler.MustResolve[rueidis.Client](b),
+	}
+	anon := privacy.New(opts)
+	other := privacy.New(opts)
+
+	now := time.Now()
+	first, err := anon.Anonymise(ctx, "81.2.69.160", now)
+	require.Nil(t, err)
+	require.NotEqual(t, "81.2.69.160", first)
+
+	// Another replica uses the same salt on the same day
+	second, err := other.Anonymise(ctx, "81.2.69.160", now)
+	require.Nil(t, err)
+	require.Equal(t, first, second)
+
+	different, err := anon.Anonymise(ctx, "81.2.69.161", now)
+	require.Nil(t, err)
+	require.NotEqual(t, first, different)
+
+	yesterday, err := anon.Anonymise(ctx, "81.2.69.160", now.Add(-time.Hour*24))
+	require.Nil(t, err)
+	require.NotEqual(t, first, yesterday)
+}
+
+func TestItFingerprintsVisitorsWithADailySalt(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	// Fingerprints are keyed in every mode, not just hash
+	anon := privacy.New(privacy.AnonymiserOpts{
+		Mode:  privacy.Full,
+		Redis: boiler.MustResolve[rueidis.Client](b),
+	})
+
+	now := time.Now()
+	first, err := anon.Fingerprint(ctx, "81.2.69.160", "curl", now)
+	require.Nil(t, err)
+	again, err := anon.Fingerprint(ctx, "81.2.69.160", "curl", now)
+	require.Nil(t, err)
+	require.Equal(t, first, again)
+
+	other, err := anon.Fingerprint(ctx, "81.2.69.160", "firefox", now)
+	require.Nil(t, err)
+	require.NotEqual(t, first, other)
+
+	yesterday, err := anon.Fingerprint(ctx, "81.2.69.160", "curl", now.Add(-time.Hour*24))
+	require.Nil(t, err)
+	require.NotEqual(t, first, yesterday)
+}
@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+type BatchOpts struct {
+	// The most tasks aggregated into a single batch
+	MaxSize int
+	// The longest a task waits before its batch is processed
+	MaxDelay time.Duration
+}
+
+// Groups without a batch task are aggregated into this, which fails
+// without being retried so the jobs are archived rather than dropped
+const unbatchableTask Task = "unbatchable"
+
+// Tasks are grouped by their kind, so each group is aggregated into the
+// batch task for that kind
+func aggregate(group string, tasks []*asynq.Task) *asynq.Task {
+	kind, ok := mapTaskToBatch(Task(group))
+	if !ok {
+		slog.Error("no batch task for group", "group", group, "tasks", len(tasks))
+		kind = unbatchableTask
+	}
+
+	jobs := make([]json.RawMessage, 0, len(tasks))
+	for _, task := range tasks {
+		if !json.Valid(task.Payload()) {
+			slog.Error("dropping task with invalid payload from batch", "group", group)
+			continue
+		}
+		jobs = append(jobs, task.Payload())
+	}
+
+	by, err := json.Marshal(struct {
+		Group string            `json:"group,omitempty"`
+		Jobs  []json.RawMessage `json:"jobs"`
+	}{Group: group, Jobs: jobs})
+	if err != nil {
+		slog.Error("could not marshal batch", "group", group, "error", err)
+	}
+	return asynq.NewTask(string(kind), by)
+}
+
+func (b *BatchOpts) apply(conf *asynq.Config) {
+	if b == nil {
+		return
+	}
+	// asynq doesn't allow a grace period under a second
+	delay := max(b.MaxDelay, time.Second)
+	conf.GroupAggregator = asynq.GroupAggregatorFunc(aggregate)
+	conf.GroupMaxSize = b.MaxSize
+	conf.GroupMaxDelay = delay
+	conf.GroupGracePeriod = delay
+}
@@ -40,6 +40,25 @@ type ServerOpts struct {
 	Redis  RedisOpts
 	// The number of concurrent jobs the worker processes (default: num cpu)
 	Concurrency int
+	// Aggregates grouped tasks into batches when set
+	Batch *BatchOpts
+	// How long to wait before retrying a task that has failed n times,
+	// uses the asynq default when nil
+	RetryDelay func(n int) time.Duration
+}
+
+// Returns a retry delay that doubles from base with each failure, up to max
+func Backoff(base, max time.Duration) func(n int) time.Duration {
+	return func(n int) time.Duration {
+		delay := base
+		for range n {
+			delay *= 2
+			if delay >= max {
+				return max
+			}
+		}
+		return min(delay, max)
+	}
 }
 
 type RedisOpts struct {
@@ -72,17 +91,21 @@ func NewWorker(ctx context.Context, opts ServerOpts) (*Worker, error) {
 	if opts.Concurrency == 0 {
 		opts.Concurrency = runtime.NumCPU()
 	}
-	srv := asynq.NewServerFromRedisClient(
-		opts.Redis.Client(),
-		asynq.Config{
-			Concurrency: opts.Concurrency,
-			BaseContext: func() context.Context { return ctx },
-			Logger: &asynqLogger{
-				log: slog.Default(),
-			},
-			Queues: queues,
+	conf := asynq.Config{
+		Concurrency: opts.Concurrency,
+		BaseContext: func() context.Context { return ctx },
+		Logger: &asynqLogger{
+			log: slog.Default(),
 		},
-	)
+		Queues: queues,
+	}
+	opts.Batch.apply(&conf)
+	if opts.RetryDelay != nil {
+		conf.RetryDelayFunc = func(n int, _ error, _ *asynq.Task) time.Duration {
+			return opts.RetryDelay(n)
+		}
+	}
+	srv := asynq.NewServerFromRedisClient(opts.Redis.Client(), conf)
 	if err := srv.Ping(); err != nil {
 		return nil, err
 	}
@@ -117,6 +140,11 @@ func (w *Worker) handler(ctx context.Context, task *asynq.Task) error {
 
 	labels := prometheus.Labels{"task": task.Type()}
 
+	if Task(task.Type()) == unbatchableTask {
+		metrics.QueueTasksProcessedErrors.With(labels).Inc()
+		return fmt.Errorf("no batch task for grouped tasks: %w", asynq.SkipRetry)
+	}
+
 	start := time.Now()
 	handler, ok := w.handlers[Task(task.Type())]
 	if !ok {
@@ -14,20 +14,31 @@ import (
 )
 
 type Publisher struct {
-	client *asynq.Client
+	client  *asynq.Client
+	batched map[Task]struct{}
 }
 
 type PublisherOpts struct {
 	Redis RedisOpts
+	// Tasks that are grouped so the consumer processes them in batches
+	Batched []Task
 }
 
 func NewPublisher(opts PublisherOpts) (*Publisher, error) {
+	batched := map[Task]struct{}{}
+	for _, kind := range opts.Batched {
+		if _, ok := mapTaskToBatch(kind); !ok {
+			return nil, fmt.Errorf("no batch task for task: %s", kind)
+		}
+		batched[kind] = struct{}{}
+	}
+
 	client := asynq.NewClientFromRedisClient(opts.Redis.Client())
 	if err := client.Ping(); err != nil {
 		return nil, err
 	}
 
-	return &Publisher{client: client}, nil
+	return &Publisher{client: client, batched: batched}, nil
 }
 
 // Push a task in the queue
@@ -49,7 +60,11 @@ func (p *Publisher) Push(ctx context.Context, kind Task, payload any) error {
 
 	span.SetAttributes(attribute.String("queue", string(queue)))
 	labels := prometheus.Labels{"queue": string(queue), "task": string(kind)}
-	if _, err = p.client.EnqueueContext(ctx, task, asynq.Queue(string(queue))); err != nil {
+	opts := []asynq.Option{asynq.Queue(string(queue))}
+	if _, ok := p.batched[kind]; ok {
+		opts = append(opts, asynq.Group(string(kind)))
+	}
+	if _, err = p.client.EnqueueContext(ctx, task, opts...); err != nil {
 		metrics.QueueTasksPushFailures.With(labels).Inc()
 	}
 	metrics.QueueTasksPushed.With(labels).Inc()
@@ -7,32 +7,76 @@ import (
 )
 
 var (
-	Create Queue = "create"
-	Click  Queue = "click"
+	Create  Queue = "create"
+	Click   Queue = "click"
+	Webhook Queue = "webhook"
 
-	CreateTask Task = "create"
-	ClickTask  Task = "click"
+	CreateTask      Task = "create"
+	CreateBatchTask Task = "create_batch"
+	ClickTask       Task = "click"
+	ClickBatchTask  Task = "click_batch"
+	WebhookTask     Task = "webhook"
 )
 
 func mapTaskToQueue(task Task) Queue {
 	switch task {
-	case CreateTask:
+	case CreateTask, CreateBatchTask:
 		return Create
-	case ClickTask:
+	case ClickTask, ClickBatchTask:
 		return Click
+	case WebhookTask:
+		return Webhook
 	default:
 		return DefaultQueue
 	}
 }
 
 type CreateJob struct {
-	ID     uuid.UUID `json:"id"`
-	Url    string    `json:"url"`
-	Domain string    `json:"domain"`
+	ID        uuid.UUID  `json:"id"`
+	Url       string     `json:"url"`
+	Domain    string     `json:"domain"`
+	Alias     string     `json:"alias,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxClicks *int       `json:"max_clicks,omitempty"`
+	Owner     *uuid.UUID `json:"owner,omitempty"`
+}
+
+type CreateBatchJob struct {
+	Urls []CreateJob `json:"urls"`
+}
+
+// Returns the task that grouped tasks of the kind are aggregated into
+func mapTaskToBatch(task Task) (Task, bool) {
+	switch task {
+	case ClickTask:
+		return ClickBatchTask, true
+	default:
+		return "", false
+	}
 }
 
 type ClickJob struct {
-	ID   uuid.UUID `json:"id"`
-	IP   string    `json:"ip"`
-	Time time.Time `json:"time"`
+	ClickID uuid.UUID  `json:"click_id"`
+	ID      uuid.UUID  `json:"id"`
+	Owner   *uuid.UUID `json:"owner,omitempty"`
+	// The ip as it should be stored, so raw ips are never queued
+	IP        string    `json:"ip"`
+	Time      time.Time `json:"time"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Language  string    `json:"language,omitempty"`
+	Country   string    `json:"country,omitempty"`
+	Region    string    `json:"region,omitempty"`
+	City      string    `json:"city,omitempty"`
+	// Whether the ip was anonymised and located before the click was queued,
+	// false for clicks queued by older versions
+	Anonymised bool `json:"anonymised,omitempty"`
+}
+
+type ClickBatchJob struct {
+	Clicks []ClickJob `json:"jobs"`
+}
+
+type WebhookJob struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
 }
@@ -0,0 +1,140 @@
+package screening
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Blocks destinations using a local file. Each line is either a domain,
+// which also blocks its subdomains, or a regex wrapped in slashes that is
+// matched against the whole url. Lines starting with # are ignored.
+type Blocklist struct {
+	path string
+
+	mu       sync.RWMutex
+	domains  map[string]struct{}
+	patterns []*regexp.Regexp
+	modified time.Time
+}
+
+func NewBlocklist(path string) (*Blocklist, error) {
+	b := &Blocklist{
+		path:    path,
+		domains: map[string]struct{}{},
+	}
+	if _, err := b.Reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Blocklist) Check(ctx context.Context, dest *url.URL) (string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	host := strings.TrimSuffix(strings.ToLower(dest.Hostname()), ".")
+	for host != "" {
+		if _, ok := b.domains[host]; ok {
+			return fmt.Sprintf("domain %s is blocklisted", host), nil
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+
+	full := dest.String()
+	for _, p := range b.patterns {
+		if p.MatchString(full) {
+			return fmt.Sprintf("url matches blocklisted pattern %s", p), nil
+		}
+	}
+
+	return "", nil
+}
+
+// Reloads the file if it has changed since it was last loaded, returning
+// whether it was reloaded. The current list is kept if the file is invalid.
+func (b *Blocklist) Reload() (bool, error) {
+	stat, err := os.Stat(b.path)
+	if err != nil {
+		return false, fmt.Errorf("stat blocklist: %w", err)
+	}
+	b.mu.RLock()
+	unchanged := stat.ModTime().Equal(b.modified)
+	b.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	f, err := os.Open(b.path)
+	if err != nil {
+		return false, fmt.Errorf("open blocklist: %w", err)
+	}
+	defer f.Close()
+
+	domains := map[string]struct{}{}
+	patterns := []*regexp.Regexp{}
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+			re, err := regexp.Compile(entry[1 : len(entry)-1])
+			if err != nil {
+				return false, fmt.Errorf("invalid blocklist pattern on line %d: %w", line, err)
+			}
+			patterns = append(patterns, re)
+			continue
+		}
+		domains[strings.TrimSuffix(strings.ToLower(entry), ".")] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("read blocklist: %w", err)
+	}
+
+	b.mu.Lock()
+	b.domains = domains
+	b.patterns = patterns
+	b.modified = stat.ModTime()
+	b.mu.Unlock()
+
+	return true, nil
+}
+
+// Reloads the file whenever it changes. Blocking.
+func (b *Blocklist) Watch(ctx context.Context, interval time.Duration) {
+	logger := slog.Default().With("subsystem", "blocklist")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := b.Reload()
+			if err != nil {
+				logger.Error("could not reload blocklist", "error", err)
+				continue
+			}
+			if reloaded {
+				logger.Info("reloaded blocklist", "path", b.path)
+			}
+		}
+	}
+}
+
+var _ Checker = &Blocklist{}
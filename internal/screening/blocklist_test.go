@@ -0,0 +1,106 @@
+package screening_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/internal/screening"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBlocklist(t *testing.T, path string, contents string, modified time.Time) {
+	require.Nil(t, os.WriteFile(path, []byte(contents), 0o644))
+	require.Nil(t, os.Chtimes(path, modified, modified))
+}
+
+func TestItBlocksDomainsAndPatterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist")
+	writeBlocklist(t, path, `
+# phishing
+evil.com
+/login\.php\?token=/
+`, time.Now())
+
+	list, err := screening.NewBlocklist(path)
+	require.Nil(t, err)
+	screener := screening.New(list)
+
+	tcs := []struct {
+		dest    string
+		blocked bool
+	}{
+		{dest: "https://evil.com", blocked: true},
+		{dest: "https://www.EVIL.com/path", blocked: true},
+		{dest: "https://notevil.com", blocked: false},
+		{dest: "https://example.com/login.php?token=bongo", blocked: true},
+		{dest: "https://example.com/login.php", blocked: false},
+	}
+	for _, c := range tcs {
+		err := screener.Screen(context.Background(), c.dest)
+		if c.blocked {
+			require.ErrorIs(t, err, screening.ErrBlocked, c.dest)
+		} else {
+			require.Nil(t, err, c.dest)
+		}
+	}
+}
+
+func TestItReloadsTheBlocklistWhenItChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist")
+	writeBlocklist(t, path, "evil.com\n", time.Now().Add(-time.Minute))
+
+	list, err := screening.NewBlocklist(path)
+	require.Nil(t, err)
+
+	reloaded, err := list.Reload()
+	require.Nil(t, err)
+	require.False(t, reloaded)
+
+	writeBlocklist(t, path, "bad.com\n", time.Now())
+	reloaded, err = list.Reload()
+	require.Nil(t, err)
+	require.True(t, reloaded)
+
+	reason, err := list.Check(context.Background(), mustParse(t, "https://evil.com"))
+	require.Nil(t, err)
+	require.Empty(t, reason)
+	reason, err = list.Check(context.Background(), mustParse(t, "https://bad.com"))
+	require.Nil(t, err)
+	require.NotEmpty(t, reason)
+
+	// An invalid file keeps the previous list
+	writeBlocklist(t, path, "/[/\n", time.Now().Add(time.Minute))
+	_, err = list.Reload()
+	require.NotNil(t, err)
+	reason, err = list.Check(context.Background(), mustParse(t, "https://bad.com"))
+	require.Nil(t, err)
+	require.NotEmpty(t, reason)
+}
+
+type stubChecker struct {
+	err error
+}
+
+func (s stubChecker) Check(context.Context, *url.URL) (string, error) {
+	return "", s.err
+}
+
+func TestItRunsRegisteredCheckers(t *testing.T) {
+	screener := screening.New()
+	require.Nil(t, screener.Screen(context.Background(), "https://example.com"))
+
+	bongo := errors.New("bongo")
+	screener.Register(stubChecker{err: bongo})
+	require.ErrorIs(t, screener.Screen(context.Background(), "https://example.com"), bongo)
+}
+
+func mustParse(t *testing.T, raw string) *url.URL {
+	u, err := url.Parse(raw)
+	require.Nil(t, err)
+	return u
+}
@@ -0,0 +1,71 @@
+package screening
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+var (
+	ErrBlocked = errors.New("destination is blocked")
+)
+
+// Checks whether a destination should be blocked
+type Checker interface {
+	// Returns why the destination should be blocked, or an empty string
+	// when it is allowed
+	Check(ctx context.Context, dest *url.URL) (string, error)
+}
+
+// Runs destinations through every registered checker
+type Screener struct {
+	mu       sync.RWMutex
+	checkers []Checker
+}
+
+func New(checkers ...Checker) *Screener {
+	return &Screener{
+		checkers: checkers,
+	}
+}
+
+// Adds an extra checker
+func (s *Screener) Register(c Checker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkers = append(s.checkers, c)
+}
+
+// Whether any checkers have been registered
+func (s *Screener) Enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.checkers) > 0
+}
+
+// Returns an error wrapping ErrBlocked with the reason when any checker
+// blocks the destination
+func (s *Screener) Screen(ctx context.Context, dest string) error {
+	s.mu.RLock()
+	checkers := s.checkers
+	s.mu.RUnlock()
+	if len(checkers) == 0 {
+		return nil
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return fmt.Errorf("parse destination: %w", err)
+	}
+	for _, c := range checkers {
+		reason, err := c.Check(ctx, u)
+		if err != nil {
+			return fmt.Errorf("screen destination: %w", err)
+		}
+		if reason != "" {
+			return fmt.Errorf("%w: %s", ErrBlocked, reason)
+		}
+	}
+	return nil
+}
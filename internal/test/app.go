@@ -5,15 +5,19 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/docker/go-connections/nat"
 	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/apikeys"
 	"github.com/henrywhitaker3/shorturl/internal/app"
 	"github.com/henrywhitaker3/shorturl/internal/config"
 	"github.com/henrywhitaker3/shorturl/internal/queue"
+	"github.com/henrywhitaker3/shorturl/internal/screening"
 	"github.com/henrywhitaker3/shorturl/internal/urls"
 	"github.com/henrywhitaker3/shorturl/internal/uuid"
 	"github.com/stretchr/testify/require"
@@ -35,20 +39,64 @@ func init() {
 }
 
 type UrlOpts struct {
-	Alias string
+	Url       string
+	Domain    string
+	Alias     string
+	ExpiresAt *time.Time
+	MaxClicks *int
+	Owner     *uuid.UUID
 }
 
 func Url(t *testing.T, b *boiler.Boiler, opts UrlOpts) *urls.Url {
+	if opts.Url == "" {
+		opts.Url = "https://example.com"
+	}
+	if opts.Domain == "" {
+		opts.Domain = "localhost"
+	}
 	require.Nil(t, boiler.MustResolve[*urls.AliasGenerator](b).Run(context.Background()))
+	if opts.Alias != "" {
+		require.Nil(t, boiler.MustResolve[*urls.Alias](b).Claim(context.Background(), opts.Alias))
+	}
 	url, err := boiler.MustResolve[urls.Urls](b).Create(context.Background(), urls.CreateParams{
-		ID:     uuid.MustOrdered(),
-		Url:    "https://example.com",
-		Domain: "localhost",
+		ID:        uuid.MustOrdered(),
+		Url:       opts.Url,
+		Domain:    opts.Domain,
+		Alias:     opts.Alias,
+		ExpiresAt: opts.ExpiresAt,
+		MaxClicks: opts.MaxClicks,
+		Owner:     opts.Owner,
 	})
 	require.Nil(t, err)
 	return url
 }
 
+// Blocks the domains for the rest of the tests using the boiler
+func Blocklist(t *testing.T, b *boiler.Boiler, domains ...string) {
+	path := filepath.Join(t.TempDir(), "blocklist")
+	require.Nil(t, os.WriteFile(path, []byte(strings.Join(domains, "\n")), 0o644))
+	list, err := screening.NewBlocklist(path)
+	require.Nil(t, err)
+	boiler.MustResolve[*screening.Screener](b).Register(list)
+}
+
+// Turns auth on until the test finishes
+func Auth(t *testing.T, b *boiler.Boiler) {
+	conf := boiler.MustResolve[*config.Config](b)
+	enabled := *conf.Auth.Enabled
+	*conf.Auth.Enabled = true
+	t.Cleanup(func() { *conf.Auth.Enabled = enabled })
+}
+
+// Creates a new api key, returning the key and its plaintext token. Auth is
+// turned on until the test finishes so the key is checked
+func ApiKey(t *testing.T, b *boiler.Boiler) (*apikeys.Key, string) {
+	Auth(t, b)
+	key, token, err := boiler.MustResolve[*apikeys.Keys](b).Create(context.Background(), Word())
+	require.Nil(t, err)
+	return key, token
+}
+
 func minio(t *testing.T, conf *config.Storage, ctx context.Context) {
 	minio, err := testcontainers.GenericContainer(
 		ctx,
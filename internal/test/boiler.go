@@ -54,7 +54,7 @@ func newBoiler(t *testing.T) *boiler.Boiler {
 	b := boiler.New(ctx)
 
 	t.Log("spinning up postgres container")
-	logger.Setup(slog.LevelDebug, testingWriter{t: t})
+	logger.Setup(ctx, slog.LevelDebug, testingWriter{t: t})
 	pgCont, err := postgres.Run(
 		ctx,
 		"postgres:17",
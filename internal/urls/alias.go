@@ -3,12 +3,60 @@ package urls
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"regexp"
 	"slices"
+	"strings"
 
 	"github.com/henrywhitaker3/shorturl/database/queries"
 )
 
+const (
+	MinAliasLength = 3
+	MaxAliasLength = 64
+)
+
+var (
+	ErrAliasTaken   = errors.New("alias is already taken")
+	ErrInvalidAlias = errors.New("invalid alias")
+	ErrNoFreeAlias  = errors.New("no free aliases available")
+
+	// Aliases that would clash with the api routes
+	ReservedAliases = []string{
+		"urls",
+		"healthz",
+		"readyz",
+		"metrics",
+		"api",
+		"webhooks",
+	}
+
+	aliasRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// Validates a user supplied vanity alias
+func ValidateAlias(alias string) error {
+	if len(alias) < MinAliasLength || len(alias) > MaxAliasLength {
+		return fmt.Errorf(
+			"%w: must be between %d and %d characters",
+			ErrInvalidAlias,
+			MinAliasLength,
+			MaxAliasLength,
+		)
+	}
+	if !aliasRegex.MatchString(alias) {
+		return fmt.Errorf(
+			"%w: can only contain letters, numbers, hyphens and underscores",
+			ErrInvalidAlias,
+		)
+	}
+	if slices.Contains(ReservedAliases, strings.ToLower(alias)) {
+		return fmt.Errorf("%w: %s is reserved", ErrInvalidAlias, alias)
+	}
+	return nil
+}
+
 type Alias struct {
 	db *queries.Queries
 }
@@ -31,6 +79,15 @@ func (a *Alias) Count(ctx context.Context) (int, error) {
 	return int(count), nil
 }
 
+// Counts the aliases with the given number of characters
+func (a *Alias) CountWithLength(ctx context.Context, length int) (int, error) {
+	count, err := a.db.CountAliasesWithLength(ctx, int32(length))
+	if err != nil {
+		return 0, fmt.Errorf("count aliases with length: %w", err)
+	}
+	return int(count), nil
+}
+
 func (a *Alias) CountFree(ctx context.Context) (int, error) {
 	count, err := a.db.CountFreeAliases(ctx)
 	if err != nil {
@@ -50,12 +107,51 @@ func (a *Alias) Store(ctx context.Context, alias string) error {
 func (a *Alias) GetFree(ctx context.Context) (string, error) {
 	alias, err := a.db.GetFreeAlias(ctx)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNoFreeAlias
+		}
 		return "", fmt.Errorf("return free alias: %w", err)
 	}
 
 	return alias.Alias, nil
 }
 
+// Marks count free aliases as used and returns them, or ErrNoFreeAlias when
+// there aren't enough. Should be called in a transaction so the aliases are
+// released if the urls aren't stored.
+func (a *Alias) ReserveFree(ctx context.Context, count int) ([]string, error) {
+	aliases, err := a.db.ReserveFreeAliases(ctx, int32(count))
+	if err != nil {
+		return nil, fmt.Errorf("reserve free aliases: %w", err)
+	}
+	if len(aliases) < count {
+		return nil, ErrNoFreeAlias
+	}
+	return aliases, nil
+}
+
+// Atomically reserves the alias, either inserting it as used or taking it from
+// the free buffer. Returns ErrAliasTaken when it is already in use.
+func (a *Alias) Claim(ctx context.Context, alias string) error {
+	_, err := a.db.ClaimAlias(ctx, alias)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAliasTaken
+		}
+		return fmt.Errorf("claim alias: %w", err)
+	}
+	return nil
+}
+
+// Releases an alias reserved with Claim when the url using it couldn't be
+// created. Aliases that belong to a url are left alone.
+func (a *Alias) Release(ctx context.Context, alias string) error {
+	if err := a.db.ReleaseAlias(ctx, alias); err != nil {
+		return fmt.Errorf("release alias: %w", err)
+	}
+	return nil
+}
+
 func (a *Alias) MarkUsed(ctx context.Context, alias string) error {
 	err := a.db.MarkAliasUsed(ctx, alias)
 	if err != nil {
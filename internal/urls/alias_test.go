@@ -0,0 +1,74 @@
+package urls_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItValidatesAliases(t *testing.T) {
+	tcs := []struct {
+		name      string
+		alias     string
+		validates bool
+	}{
+		{
+			name:      "validates a simple alias",
+			alias:     "spring-sale",
+			validates: true,
+		},
+		{
+			name:      "validates mixed case with underscores",
+			alias:     "Spring_Sale_2025",
+			validates: true,
+		},
+		{
+			name:      "fails when too short",
+			alias:     "ab",
+			validates: false,
+		},
+		{
+			name:      "fails when too long",
+			alias:     strings.Repeat("a", urls.MaxAliasLength+1),
+			validates: false,
+		},
+		{
+			name:      "fails with a slash",
+			alias:     "spring/sale",
+			validates: false,
+		},
+		{
+			name:      "fails with a space",
+			alias:     "spring sale",
+			validates: false,
+		},
+		{
+			name:      "fails for reserved words",
+			alias:     "urls",
+			validates: false,
+		},
+		{
+			name:      "fails for reserved words in a different case",
+			alias:     "Healthz",
+			validates: false,
+		},
+		{
+			name:      "fails for the webhooks route",
+			alias:     "webhooks",
+			validates: false,
+		},
+	}
+
+	for _, c := range tcs {
+		t.Run(c.name, func(t *testing.T) {
+			err := urls.ValidateAlias(c.alias)
+			if c.validates {
+				require.Nil(t, err)
+			} else {
+				require.ErrorIs(t, err, urls.ErrInvalidAlias)
+			}
+		})
+	}
+}
@@ -2,27 +2,41 @@ package urls
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/henrywhitaker3/shorturl/internal/lru"
 	"github.com/henrywhitaker3/shorturl/internal/uuid"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/rueidis"
+)
+
+const (
+	invalidateChannel = "urls:invalidate"
 )
 
 type Cache struct {
 	svc   *Service
 	cache *lru.LRU[string, *Url]
+	redis rueidis.Client
 
-	keys   prometheus.Gauge
-	hits   prometheus.Counter
-	misses prometheus.Counter
+	keys          prometheus.Gauge
+	hits          prometheus.Counter
+	misses        prometheus.Counter
+	invalidations prometheus.Counter
 }
 
 type CacheOpts struct {
 	Service  *Service
 	Size     int
 	Registry prometheus.Registerer
+	// Used to broadcast invalidations to the other replicas, when nil only
+	// the local cache is invalidated
+	Redis rueidis.Client
 }
 
 func NewCache(opts CacheOpts) (*Cache, error) {
@@ -33,6 +47,7 @@ func NewCache(opts CacheOpts) (*Cache, error) {
 	c := &Cache{
 		svc:   opts.Service,
 		cache: cache,
+		redis: opts.Redis,
 		keys: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "url_cache_keys",
 		}),
@@ -42,6 +57,9 @@ func NewCache(opts CacheOpts) (*Cache, error) {
 		misses: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "url_cache_misses_total",
 		}),
+		invalidations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "url_cache_invalidations_total",
+		}),
 	}
 
 	if opts.Registry != nil {
@@ -54,13 +72,16 @@ func NewCache(opts CacheOpts) (*Cache, error) {
 		if err := opts.Registry.Register(c.misses); err != nil {
 			slog.Error("failed to register cache metric", "metric", "misses")
 		}
+		if err := opts.Registry.Register(c.invalidations); err != nil {
+			slog.Error("failed to register cache metric", "metric", "invalidations")
+		}
 	}
 
 	return c, nil
 }
 
 func (c *Cache) Get(ctx context.Context, id uuid.UUID) (*Url, error) {
-	url, ok := c.cache.Get(id.String())
+	url, ok := c.get(id.String())
 	if !ok {
 		c.misses.Inc()
 		var err error
@@ -71,13 +92,12 @@ func (c *Cache) Get(ctx context.Context, id uuid.UUID) (*Url, error) {
 	} else {
 		c.hits.Inc()
 	}
-	c.cache.Add(id.String(), url)
-	c.keys.Set(float64(c.cache.Len()))
+	c.add(id.String(), url)
 	return url, nil
 }
 
 func (c *Cache) GetAlias(ctx context.Context, alias string) (*Url, error) {
-	url, ok := c.cache.Get(alias)
+	url, ok := c.get(alias)
 	if !ok {
 		c.misses.Inc()
 		var err error
@@ -88,11 +108,101 @@ func (c *Cache) GetAlias(ctx context.Context, alias string) (*Url, error) {
 	} else {
 		c.hits.Inc()
 	}
-	c.cache.Add(alias, url)
-	c.keys.Set(float64(c.cache.Len()))
+	c.add(alias, url)
 	return url, nil
 }
 
+// Gets the url from the lru, treating expired urls as a miss
+func (c *Cache) get(key string) (*Url, bool) {
+	url, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if url.Expired() {
+		c.forget(url)
+		return nil, false
+	}
+	return url, true
+}
+
+// Adds the url to the lru, expired urls are never cached
+func (c *Cache) add(key string, url *Url) {
+	if url.Expired() {
+		return
+	}
+	c.cache.Add(key, url)
+	c.keys.Set(float64(c.cache.Len()))
+}
+
+// Removes all the keys for the url from the lru
+func (c *Cache) forget(url *Url) {
+	c.remove(url.ID.String(), url.Alias)
+}
+
+func (c *Cache) remove(keys ...string) {
+	for _, key := range keys {
+		c.cache.Remove(key)
+	}
+	c.keys.Set(float64(c.cache.Len()))
+}
+
+type invalidation struct {
+	ID    string `json:"id"`
+	Alias string `json:"alias"`
+}
+
+// Removes the url from the local lru and tells the other replicas to do the same
+func (c *Cache) invalidate(ctx context.Context, url *Url) error {
+	c.forget(url)
+	if c.redis == nil {
+		return nil
+	}
+
+	by, err := json.Marshal(invalidation{
+		ID:    url.ID.String(),
+		Alias: url.Alias,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal cache invalidation: %w", err)
+	}
+	cmd := c.redis.B().Publish().Channel(invalidateChannel).Message(string(by)).Build()
+	if err := c.redis.Do(ctx, cmd).Error(); err != nil {
+		return fmt.Errorf("publish cache invalidation: %w", err)
+	}
+	return nil
+}
+
+// Listens for invalidations broadcast by other replicas. Blocking.
+func (c *Cache) Listen(ctx context.Context) {
+	if c.redis == nil {
+		return
+	}
+	logger := slog.Default().With("subsystem", "cache")
+	cmd := c.redis.B().Subscribe().Channel(invalidateChannel).Build()
+	for {
+		err := c.redis.Receive(ctx, cmd, func(msg rueidis.PubSubMessage) {
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Message), &inv); err != nil {
+				logger.Error("could not unmarshal cache invalidation", "error", err)
+				return
+			}
+			c.invalidations.Inc()
+			c.remove(inv.ID, inv.Alias)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.Error("cache invalidation subscription failed, resubscribing", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
 func (c *Cache) Count(ctx context.Context) (int, error) {
 	return c.svc.Count(ctx)
 }
@@ -101,4 +211,76 @@ func (c *Cache) Create(ctx context.Context, params CreateParams) (*Url, error) {
 	return c.svc.Create(ctx, params)
 }
 
+func (c *Cache) GetByDestination(
+	ctx context.Context,
+	url string,
+	owner *uuid.UUID,
+) (*Url, error) {
+	return c.svc.GetByDestination(ctx, url, owner)
+}
+
+func (c *Cache) CreateBatch(ctx context.Context, params []CreateParams) ([]*Url, error) {
+	return c.svc.CreateBatch(ctx, params)
+}
+
+func (c *Cache) List(ctx context.Context, params ListParams) (*UrlPage, error) {
+	return c.svc.List(ctx, params)
+}
+
+func (c *Cache) Update(ctx context.Context, params UpdateParams) (*Url, error) {
+	url, err := c.svc.Update(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.invalidate(ctx, url); err != nil {
+		return nil, err
+	}
+	return url, nil
+}
+
+func (c *Cache) Quarantine(ctx context.Context, id uuid.UUID, reason string) (*Url, error) {
+	url, err := c.svc.Quarantine(ctx, id, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.invalidate(ctx, url); err != nil {
+		return nil, err
+	}
+	return url, nil
+}
+
+func (c *Cache) Unquarantine(ctx context.Context, id uuid.UUID) (*Url, error) {
+	url, err := c.svc.Unquarantine(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.invalidate(ctx, url); err != nil {
+		return nil, err
+	}
+	return url, nil
+}
+
+func (c *Cache) Delete(ctx context.Context, id uuid.UUID) error {
+	url, err := c.svc.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := c.svc.Delete(ctx, id); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, url)
+}
+
+func (c *Cache) Visit(ctx context.Context, url *Url) error {
+	err := c.svc.Visit(ctx, url)
+	if errors.Is(err, ErrExpired) || errors.Is(err, sql.ErrNoRows) {
+		c.forget(url)
+	}
+	return err
+}
+
+func (c *Cache) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	return c.svc.DeleteExpired(ctx, before)
+}
+
 var _ Urls = &Cache{}
@@ -0,0 +1,60 @@
+package urls_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/database/queries"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItInvalidatesOtherReplicaCaches(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	svc := urls.New(urls.ServiceOpts{
+		DB:    boiler.MustResolve[*queries.Queries](b),
+		Conn:  boiler.MustResolve[*sql.DB](b),
+		Alias: boiler.MustResolve[*urls.Alias](b),
+	})
+	redis := boiler.MustResolve[rueidis.Client](b)
+
+	replicas := []*urls.Cache{}
+	for range 2 {
+		cache, err := urls.NewCache(urls.CacheOpts{
+			Service: svc,
+			Size:    10,
+			Redis:   redis,
+		})
+		require.Nil(t, err)
+		go cache.Listen(ctx)
+		replicas = append(replicas, cache)
+	}
+	// Give the subscriptions time to start
+	time.Sleep(time.Millisecond * 500)
+
+	url := test.Url(t, b, test.UrlOpts{})
+	for _, r := range replicas {
+		_, err := r.GetAlias(ctx, url.Alias)
+		require.Nil(t, err)
+	}
+
+	_, err := replicas[0].Update(ctx, urls.UpdateParams{
+		ID:  url.ID,
+		Url: "https://synthetigo.com",
+	})
+	require.Nil(t, err)
+
+	require.Eventually(t, func() bool {
+		got, err := replicas[1].GetAlias(ctx, url.Alias)
+		return err == nil && got.Url == "https://synthetigo.com"
+	}, time.Second*5, time.Millisecond*100)
+}
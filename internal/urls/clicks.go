@@ -2,82 +2,514 @@ package urls
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/henrywhitaker3/shorturl/database/queries"
+	"github.com/henrywhitaker3/shorturl/internal/geoip"
+	"github.com/henrywhitaker3/shorturl/internal/logger"
+	"github.com/henrywhitaker3/shorturl/internal/metrics"
+	"github.com/henrywhitaker3/shorturl/internal/privacy"
 	"github.com/henrywhitaker3/shorturl/internal/queue"
+	"github.com/henrywhitaker3/shorturl/internal/useragent"
 	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/henrywhitaker3/shorturl/internal/webhooks"
 	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Clicks struct {
-	db *queries.Queries
+	db   *queries.Queries
+	conn *sql.DB
 }
 
 type ClickOpts struct {
-	DB *queries.Queries
+	DB   *queries.Queries
+	Conn *sql.DB
 }
 
 func NewClicks(opts ClickOpts) *Clicks {
 	return &Clicks{
-		db: opts.DB,
+		db:   opts.DB,
+		conn: opts.Conn,
 	}
 }
 
+// The longest header value stored against a click, anything longer is
+// truncated
+const maxHeaderLength = 1024
+
+const foreignKeyViolation = "23503"
+
+func asPgError(err error) (*pgconn.PgError, bool) {
+	var pg *pgconn.PgError
+	if errors.As(err, &pg) {
+		return pg, true
+	}
+	return nil, false
+}
+
 type StoreClick struct {
-	ID   uuid.UUID
-	IP   string
-	Time time.Time
+	// Used to dedupe clicks that are stored more than once, generated
+	// when empty
+	ClickID   uuid.UUID
+	ID        uuid.UUID
+	IP        string
+	Time      time.Time
+	Referrer  string
+	UserAgent string
+	// The raw Accept-Language header
+	Language string
+	Location geoip.Location
 }
 
 func (c *Clicks) Click(ctx context.Context, params StoreClick) error {
-	id, err := uuid.Ordered()
+	row, err := clickRow(params)
 	if err != nil {
-		return fmt.Errorf("generate click id: %w", err)
+		return err
 	}
-
-	if err := c.db.StoreClick(ctx, queries.StoreClickParams{
-		ID:        id.UUID(),
-		UrlID:     params.ID.UUID(),
-		Ip:        params.IP,
-		ClickedAt: params.Time.Unix(),
-	}); err != nil {
+	if err := c.db.StoreClick(ctx, row); err != nil {
+		// The url was deleted after it was clicked, so there's nothing to
+		// store the click against
+		if pgErr, ok := asPgError(err); ok && pgErr.Code == foreignKeyViolation {
+			return nil
+		}
 		return fmt.Errorf("store click: %w", err)
 	}
 
 	return nil
 }
 
+// Stores the clicks with a single insert, skipping any that have already
+// been stored or whose url has been deleted. Returns the number of clicks
+// inserted.
+func (c *Clicks) ClickBatch(ctx context.Context, clicks []StoreClick) (int, error) {
+	args := queries.StoreClicksParams{}
+	for _, click := range clicks {
+		row, err := clickRow(click)
+		if err != nil {
+			return 0, err
+		}
+		args.Ids = append(args.Ids, row.ID)
+		args.UrlIds = append(args.UrlIds, row.UrlID)
+		args.Ips = append(args.Ips, row.Ip)
+		args.ClickedAts = append(args.ClickedAts, row.ClickedAt)
+		args.Referrers = append(args.Referrers, row.Referrer)
+		args.ReferrerDomains = append(args.ReferrerDomains, row.ReferrerDomain)
+		args.UserAgents = append(args.UserAgents, row.UserAgent)
+		args.Languages = append(args.Languages, row.Language)
+		args.Browsers = append(args.Browsers, row.Browser)
+		args.Oses = append(args.Oses, row.Os)
+		args.Devices = append(args.Devices, row.Device)
+		args.Bots = append(args.Bots, row.Bot)
+		args.Countries = append(args.Countries, row.Country)
+		args.Regions = append(args.Regions, row.Region)
+		args.Cities = append(args.Cities, row.City)
+	}
+
+	inserted, err := c.db.StoreClicks(ctx, args)
+	if err != nil {
+		return 0, fmt.Errorf("store clicks: %w", err)
+	}
+	return int(inserted), nil
+}
+
+func clickRow(params StoreClick) (queries.StoreClickParams, error) {
+	id := params.ClickID
+	if id == (uuid.UUID{}) {
+		var err error
+		id, err = uuid.Ordered()
+		if err != nil {
+			return queries.StoreClickParams{}, fmt.Errorf("generate click id: %w", err)
+		}
+	}
+
+	// Headers can contain invalid utf-8, which postgres won't store
+	referrer := strings.ToValidUTF8(params.Referrer, "")
+	userAgent := strings.ToValidUTF8(params.UserAgent, "")
+	agent := useragent.Parse(userAgent)
+
+	return queries.StoreClickParams{
+		ID:             id.UUID(),
+		UrlID:          params.ID.UUID(),
+		Ip:             params.IP,
+		ClickedAt:      params.Time.Unix(),
+		Referrer:       truncate(referrer, maxHeaderLength),
+		ReferrerDomain: referrerDomain(referrer),
+		UserAgent:      truncate(userAgent, maxHeaderLength),
+		Language:       useragent.Language(strings.ToValidUTF8(params.Language, "")),
+		Browser:        agent.Browser,
+		Os:             agent.OS,
+		Device:         string(agent.Device),
+		Bot:            agent.Bot,
+		Country:        params.Location.Country,
+		Region:         params.Location.Region,
+		City:           params.Location.City,
+	}, nil
+}
+
+func referrerDomain(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	parsed, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// Cuts the string down to at most length bytes without splitting a rune
+func truncate(in string, length int) string {
+	if len(in) <= length {
+		return in
+	}
+	for length > 0 && !utf8.RuneStart(in[length]) {
+		length--
+	}
+	return in[:length]
+}
+
+// The number of values returned for each breakdown dimension
+const breakdownSize = 10
+
+type Count struct {
+	Value  string `json:"value"`
+	Clicks int    `json:"clicks"`
+}
+
+type Breakdown struct {
+	Referrers        []*Count `json:"referrers"`
+	Browsers         []*Count `json:"browsers"`
+	OperatingSystems []*Count `json:"operating_systems"`
+	Devices          []*Count `json:"devices"`
+	Languages        []*Count `json:"languages"`
+	Countries        []*Count `json:"countries"`
+	Regions          []*Count `json:"regions"`
+	Cities           []*Count `json:"cities"`
+}
+
 type Stats struct {
-	Clicks int `json:"clicks"`
+	Clicks    int        `json:"clicks"`
+	Bots      int        `json:"bots"`
+	Breakdown *Breakdown `json:"breakdown"`
 }
 
+// Stats are read from the rollups, with the clicks that haven't been rolled
+// up yet counted from the raw clicks
 func (c *Clicks) Stats(ctx context.Context, id uuid.UUID) (*Stats, error) {
 	clicks, err := c.db.CountClicks(ctx, id.UUID())
 	if err != nil {
 		return nil, fmt.Errorf("could not count clicks: %w", err)
 	}
-	return &Stats{
+	rows, err := c.db.GetClickBreakdown(ctx, id.UUID())
+	if err != nil {
+		return nil, fmt.Errorf("could not get click breakdown: %w", err)
+	}
+
+	out := &Stats{
 		Clicks: int(clicks),
-	}, nil
+		Breakdown: &Breakdown{
+			Referrers:        []*Count{},
+			Browsers:         []*Count{},
+			OperatingSystems: []*Count{},
+			Devices:          []*Count{},
+			Languages:        []*Count{},
+			Countries:        []*Count{},
+			Regions:          []*Count{},
+			Cities:           []*Count{},
+		},
+	}
+	for _, row := range rows {
+		count := &Count{Value: row.Value, Clicks: int(row.Clicks)}
+		switch row.Dimension {
+		case "referrer":
+			if count.Value == "" {
+				count.Value = "direct"
+			}
+			out.Breakdown.Referrers = append(out.Breakdown.Referrers, count)
+		case "browser":
+			out.Breakdown.Browsers = append(out.Breakdown.Browsers, known(count))
+		case "os":
+			out.Breakdown.OperatingSystems = append(out.Breakdown.OperatingSystems, known(count))
+		case "device":
+			out.Breakdown.Devices = append(out.Breakdown.Devices, known(count))
+		case "language":
+			out.Breakdown.Languages = append(out.Breakdown.Languages, known(count))
+		case "country":
+			out.Breakdown.Countries = append(out.Breakdown.Countries, known(count))
+		case "region":
+			out.Breakdown.Regions = append(out.Breakdown.Regions, known(count))
+		case "city":
+			out.Breakdown.Cities = append(out.Breakdown.Cities, known(count))
+		case "bot":
+			if row.Value == "true" {
+				out.Bots = count.Clicks
+			}
+		}
+	}
+
+	out.Breakdown.Referrers = top(out.Breakdown.Referrers)
+	out.Breakdown.Browsers = top(out.Breakdown.Browsers)
+	out.Breakdown.OperatingSystems = top(out.Breakdown.OperatingSystems)
+	out.Breakdown.Devices = top(out.Breakdown.Devices)
+	out.Breakdown.Languages = top(out.Breakdown.Languages)
+	out.Breakdown.Countries = top(out.Breakdown.Countries)
+	out.Breakdown.Regions = top(out.Breakdown.Regions)
+	out.Breakdown.Cities = top(out.Breakdown.Cities)
+
+	return out, nil
+}
+
+// Clicks stored before the extra click details were captured, or that
+// couldn't be located, have empty values so they are reported as unknown
+func known(count *Count) *Count {
+	if count.Value == "" {
+		count.Value = string(useragent.Unknown)
+	}
+	return count
+}
+
+func top(counts []*Count) []*Count {
+	slices.SortFunc(counts, func(a, b *Count) int {
+		if a.Clicks != b.Clicks {
+			return b.Clicks - a.Clicks
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+	if len(counts) > breakdownSize {
+		return counts[:breakdownSize]
+	}
+	return counts
+}
+
+type Granularity string
+
+const (
+	Minute Granularity = "minute"
+	Hour   Granularity = "hour"
+	Day    Granularity = "day"
+)
+
+func (g Granularity) Valid() bool {
+	return g == Minute || g == Hour || g == Day
+}
+
+func (g Granularity) Duration() time.Duration {
+	switch g {
+	case Minute:
+		return time.Minute
+	case Hour:
+		return time.Hour
+	default:
+		return time.Hour * 24
+	}
+}
+
+type Bucket struct {
+	Time      time.Time `json:"time"`
+	Clicks    int       `json:"clicks"`
+	UniqueIPs int       `json:"unique_ips"`
+}
+
+type SeriesParams struct {
+	ID          uuid.UUID
+	Granularity Granularity
+	Since       time.Time
+	Until       time.Time
+}
+
+// Returns the clicks in each bucket between since and until, including
+// the buckets without any clicks. Hourly and daily series are read from the
+// rollups, so they are kept after the raw clicks are deleted.
+func (c *Clicks) Series(ctx context.Context, params SeriesParams) ([]*Bucket, error) {
+	step := params.Granularity.Duration()
+	since := params.Since.UTC().Truncate(step)
+
+	counts, err := c.series(ctx, params.ID, params.Granularity, since, params.Until)
+	if err != nil {
+		return nil, fmt.Errorf("could not get click series: %w", err)
+	}
+
+	out := []*Bucket{}
+	for at := since; at.Before(params.Until); at = at.Add(step) {
+		bucket := &Bucket{Time: at}
+		if count, ok := counts[at.Unix()]; ok {
+			bucket.Clicks = count.Clicks
+			bucket.UniqueIPs = count.UniqueIPs
+		}
+		out = append(out, bucket)
+	}
+	return out, nil
+}
+
+func (c *Clicks) series(
+	ctx context.Context,
+	id uuid.UUID,
+	granularity Granularity,
+	since, until time.Time,
+) (map[int64]*Bucket, error) {
+	out := map[int64]*Bucket{}
+	switch granularity {
+	case Hour:
+		rows, err := c.db.GetHourlyClickSeries(ctx, queries.GetHourlyClickSeriesParams{
+			UrlID: id.UUID(),
+			Since: since.Unix(),
+			Until: until.Unix(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			out[row.Bucket] = &Bucket{Clicks: int(row.Clicks), UniqueIPs: int(row.UniqueIps)}
+		}
+	case Day:
+		rows, err := c.db.GetDailyClickSeries(ctx, queries.GetDailyClickSeriesParams{
+			UrlID: id.UUID(),
+			Since: since.Unix(),
+			Until: until.Unix(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			out[row.Bucket] = &Bucket{Clicks: int(row.Clicks), UniqueIPs: int(row.UniqueIps)}
+		}
+	default:
+		rows, err := c.db.GetClickSeries(ctx, queries.GetClickSeriesParams{
+			Unit:  string(granularity),
+			UrlID: id.UUID(),
+			Since: since.Unix(),
+			Until: until.Unix(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			out[row.Bucket] = &Bucket{Clicks: int(row.Clicks), UniqueIPs: int(row.UniqueIps)}
+		}
+	}
+	return out, nil
+}
+
+// Adds the clicks stored since the last rollup to the hourly, daily and
+// breakdown rollups. Clicks stored within the delay are left for the next
+// rollup, so inserts that haven't committed yet aren't skipped.
+func (c *Clicks) Rollup(ctx context.Context, delay time.Duration) error {
+	tx, err := c.conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("start db transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := c.db.WithTx(tx)
+
+	// Locked so concurrent rollups don't add the same clicks twice
+	since, err := db.LockRollupWatermark(ctx)
+	if err != nil {
+		return fmt.Errorf("could not lock rollup watermark: %w", err)
+	}
+	until, err := db.AdvanceRollupWatermark(ctx, delay.Microseconds())
+	if err != nil {
+		return fmt.Errorf("could not advance rollup watermark: %w", err)
+	}
+	if until <= since {
+		return nil
+	}
+
+	if err := db.RollupHourlyClicks(ctx, queries.RollupHourlyClicksParams{
+		Since: since,
+		Until: until,
+	}); err != nil {
+		return fmt.Errorf("could not rollup hourly clicks: %w", err)
+	}
+	// Derived from the hourly rollups, so must run after them
+	if err := db.RollupDailyClicks(ctx, queries.RollupDailyClicksParams{
+		Since: since,
+		Until: until,
+	}); err != nil {
+		return fmt.Errorf("could not rollup daily clicks: %w", err)
+	}
+	if err := db.RollupClickBreakdown(ctx, queries.RollupClickBreakdownParams{
+		Since: since,
+		Until: until,
+	}); err != nil {
+		return fmt.Errorf("could not rollup click breakdown: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit rollup: %w", err)
+	}
+	return nil
 }
 
+// Drops the daily partitions of clicks that are entirely older than the
+// cutoff, returning the number of partitions dropped. Clicks in the current
+// day's partition are kept until the whole day has expired.
 func (c *Clicks) Delete(ctx context.Context, olderThan time.Time) (int, error) {
-	deleted, err := c.db.DeleteClicks(ctx, olderThan.Unix())
+	dropped, err := c.db.PruneClicks(ctx, olderThan.Unix())
 	if err != nil {
 		return 0, fmt.Errorf("could not delete clicks: %w", err)
 	}
-	return int(deleted), nil
+	return int(dropped), nil
+}
+
+// Creates the daily partitions for clicks between from and to, skipping
+// any that already exist
+func (c *Clicks) CreatePartitions(ctx context.Context, from, to time.Time) error {
+	for day := from.UTC().Truncate(time.Hour * 24); !day.After(to); day = day.Add(time.Hour * 24) {
+		if err := c.db.CreateClicksPartition(ctx, day.Unix()); err != nil {
+			return fmt.Errorf("could not create clicks partition for %s: %w", day.Format(time.DateOnly), err)
+		}
+	}
+	return nil
 }
 
 type ClickJobHandler struct {
-	svc *Clicks
+	svc        *Clicks
+	geo        *geoip.Reader
+	anonymiser *privacy.Anonymiser
+	stream     *ClickStream
+	webhooks   *webhooks.Webhooks
+	top        *Leaderboard
 }
 
-func NewClickJobHandler(svc *Clicks) *ClickJobHandler {
-	return &ClickJobHandler{svc: svc}
+type ClickJobHandlerOpts struct {
+	Clicks *Clicks
+	// Used for clicks queued before they were anonymised by the app servers
+	Anonymiser *privacy.Anonymiser
+	// Clicks queued before they were located by the app servers are only
+	// located when not nil
+	Geo *geoip.Reader
+	// Clicks are only published to live streams when not nil
+	Stream *ClickStream
+	// Clicks are only sent to webhooks when not nil
+	Webhooks *webhooks.Webhooks
+	// Clicks are only counted for the top urls when not nil
+	Leaderboard *Leaderboard
+}
+
+func NewClickJobHandler(opts ClickJobHandlerOpts) *ClickJobHandler {
+	return &ClickJobHandler{
+		svc:        opts.Clicks,
+		geo:        opts.Geo,
+		anonymiser: opts.Anonymiser,
+		stream:     opts.Stream,
+		webhooks:   opts.Webhooks,
+		top:        opts.Leaderboard,
+	}
+}
+
+// The data sent to webhooks for url.clicked events
+type ClickedEvent struct {
+	UrlID uuid.UUID `json:"url_id"`
+	ClickEvent
 }
 
 func (c *ClickJobHandler) Handle(ctx context.Context, payload []byte) error {
@@ -86,12 +518,132 @@ func (c *ClickJobHandler) Handle(ctx context.Context, payload []byte) error {
 		return fmt.Errorf("unmarshal click job: %w %w", err, asynq.SkipRetry)
 	}
 
-	if err := c.svc.Click(ctx, StoreClick{
-		ID:   job.ID,
-		IP:   job.IP,
-		Time: job.Time,
-	}); err != nil {
+	click, err := c.click(ctx, job)
+	if err != nil {
+		return err
+	}
+	if err := c.svc.Click(ctx, click); err != nil {
 		return fmt.Errorf("store click: %w", err)
 	}
+	c.publish(ctx, []queue.ClickJob{job}, []StoreClick{click})
+	c.count(ctx, []queue.ClickJob{job})
+	return nil
+}
+
+// Counts the clicks for the top urls. Failures are only logged, as retrying
+// the job would store the clicks again.
+func (c *ClickJobHandler) count(ctx context.Context, jobs []queue.ClickJob) {
+	for _, job := range jobs {
+		// Clicks queued by older versions were counted when they were visited
+		if !job.Anonymised {
+			continue
+		}
+		if c.top != nil {
+			if err := c.top.Click(ctx, job.ID, job.Owner, job.Time); err != nil {
+				logger.Logger(ctx).Error("failed to count click for top urls", "error", err)
+			}
+		}
+	}
+}
+
+// Publishes stored clicks to live streams and webhooks. Failures are only
+// logged, as retrying the job would store the clicks again.
+func (c *ClickJobHandler) publish(ctx context.Context, jobs []queue.ClickJob, clicks []StoreClick) {
+	if c.stream != nil {
+		if err := c.stream.Publish(ctx, clicks); err != nil {
+			logger.Logger(ctx).Error("failed to publish clicks", "error", err)
+		}
+	}
+	if c.webhooks != nil {
+		events := make([]*webhooks.Event, 0, len(clicks))
+		for i, click := range clicks {
+			event := webhooks.NewEvent(webhooks.UrlClicked, jobs[i].Owner, ClickedEvent{
+				UrlID:      click.ID,
+				ClickEvent: clickEvent(click),
+			})
+			// Use the click's id, so receivers can ignore clicks sent again
+			// when a batch is retried
+			if click.ClickID != (uuid.UUID{}) {
+				event.ID = click.ClickID
+			}
+			events = append(events, event)
+		}
+		c.webhooks.Dispatch(ctx, events...)
+	}
+}
+
+func (c *ClickJobHandler) click(ctx context.Context, job queue.ClickJob) (StoreClick, error) {
+	click := StoreClick{
+		ClickID:   job.ClickID,
+		ID:        job.ID,
+		IP:        job.IP,
+		Time:      job.Time,
+		Referrer:  job.Referrer,
+		UserAgent: job.UserAgent,
+		Language:  job.Language,
+		Location: geoip.Location{
+			Country: job.Country,
+			Region:  job.Region,
+			City:    job.City,
+		},
+	}
+	if job.Anonymised {
+		return click, nil
+	}
+
+	if c.geo != nil {
+		click.Location = c.geo.Lookup(job.IP)
+	}
+	// Located first, as the location can't be found from an anonymised ip
+	ip, err := c.anonymiser.Anonymise(ctx, job.IP, job.Time)
+	if err != nil {
+		return StoreClick{}, fmt.Errorf("anonymise ip: %w", err)
+	}
+	click.IP = ip
+	return click, nil
+}
+
+// Stores batches of clicks aggregated by the queue. A failed batch is
+// retried as a whole, with clicks that were already stored being skipped.
+type ClickBatchJobHandler struct {
+	clicks *ClickJobHandler
+}
+
+func NewClickBatchJobHandler(clicks *ClickJobHandler) *ClickBatchJobHandler {
+	return &ClickBatchJobHandler{clicks: clicks}
+}
+
+func (c *ClickBatchJobHandler) Handle(ctx context.Context, payload []byte) error {
+	job := queue.ClickBatchJob{}
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("unmarshal click batch job: %w %w", err, asynq.SkipRetry)
+	}
+	if len(job.Clicks) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	clicks := make([]StoreClick, 0, len(job.Clicks))
+	for _, item := range job.Clicks {
+		click, err := c.clicks.click(ctx, item)
+		if err != nil {
+			return err
+		}
+		clicks = append(clicks, click)
+	}
+
+	inserted, err := c.clicks.svc.ClickBatch(ctx, clicks)
+	if err != nil {
+		return fmt.Errorf("store click batch: %w", err)
+	}
+
+	c.clicks.publish(ctx, job.Clicks, clicks)
+	c.clicks.count(ctx, job.Clicks)
+
+	metrics.ClickBatchSize.Observe(float64(len(clicks)))
+	metrics.ClickBatchFlushDuration.Observe(time.Since(start).Seconds())
+	if duplicates := len(clicks) - inserted; duplicates > 0 {
+		metrics.ClickBatchDuplicates.Add(float64(duplicates))
+	}
 	return nil
 }
@@ -0,0 +1,29 @@
+package urls
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestItTruncatesOnARuneBoundary(t *testing.T) {
+	require.Equal(t, "abc", truncate("abc", 5))
+	require.Equal(t, "ab", truncate("abc", 2))
+	// é is 2 bytes, so cutting after its first byte drops it
+	require.Equal(t, "a", truncate("aé", 2))
+	require.Equal(t, "aé", truncate("aéb", 3))
+}
+
+func TestItStoresValidUtf8FromHeaders(t *testing.T) {
+	row, err := clickRow(StoreClick{
+		Referrer:  "https://example.com/\xff\xfe",
+		UserAgent: strings.Repeat("é", maxHeaderLength) + "\xc3",
+	})
+	require.Nil(t, err)
+	require.True(t, utf8.ValidString(row.Referrer))
+	require.Equal(t, "https://example.com/", row.Referrer)
+	require.True(t, utf8.ValidString(row.UserAgent))
+	require.LessOrEqual(t, len(row.UserAgent), maxHeaderLength)
+}
@@ -0,0 +1,194 @@
+package urls_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/geoip"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItBreaksDownClicks(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	url := test.Url(t, b, test.UrlOpts{})
+
+	clicks := boiler.MustResolve[*urls.Clicks](b)
+
+	chrome := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+	for _, click := range []urls.StoreClick{
+		{
+			Referrer:  "https://www.google.com/search?q=bongo",
+			UserAgent: chrome,
+			Language:  "en-GB,en;q=0.9",
+			Location:  geoip.Location{Country: "GB", Region: "England", City: "London"},
+		},
+		{
+			Referrer:  "https://google.com/",
+			UserAgent: chrome,
+			Language:  "en-GB",
+			Location:  geoip.Location{Country: "GB"},
+		},
+		{UserAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"},
+	} {
+		click.ID = url.ID
+		click.IP = "127.0.0.1"
+		click.Time = time.Now()
+		require.Nil(t, clicks.Click(ctx, click))
+	}
+	require.Nil(t, clicks.Rollup(ctx, 0))
+
+	stats, err := clicks.Stats(ctx, url.ID)
+	require.Nil(t, err)
+	require.Equal(t, 3, stats.Clicks)
+	require.Equal(t, 1, stats.Bots)
+	require.Equal(t, []*urls.Count{
+		{Value: "google.com", Clicks: 2},
+		{Value: "direct", Clicks: 1},
+	}, stats.Breakdown.Referrers)
+	require.Equal(t, []*urls.Count{
+		{Value: "Chrome", Clicks: 2},
+		{Value: "unknown", Clicks: 1},
+	}, stats.Breakdown.Browsers)
+	require.Equal(t, []*urls.Count{
+		{Value: "desktop", Clicks: 2},
+		{Value: "bot", Clicks: 1},
+	}, stats.Breakdown.Devices)
+	require.Equal(t, []*urls.Count{
+		{Value: "en-gb", Clicks: 2},
+		{Value: "unknown", Clicks: 1},
+	}, stats.Breakdown.Languages)
+	require.Equal(t, []*urls.Count{
+		{Value: "GB", Clicks: 2},
+		{Value: "unknown", Clicks: 1},
+	}, stats.Breakdown.Countries)
+	require.Equal(t, []*urls.Count{
+		{Value: "unknown", Clicks: 2},
+		{Value: "London, GB", Clicks: 1},
+	}, stats.Breakdown.Cities)
+}
+
+func TestItStoresClicksInBatchesOnce(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	url := test.Url(t, b, test.UrlOpts{})
+
+	clicks := boiler.MustResolve[*urls.Clicks](b)
+
+	batch := []urls.StoreClick{}
+	for range 5 {
+		batch = append(batch, urls.StoreClick{
+			ClickID: uuid.MustOrdered(),
+			ID:      url.ID,
+			IP:      "127.0.0.1",
+			Time:    time.Now(),
+		})
+	}
+
+	inserted, err := clicks.ClickBatch(ctx, batch)
+	require.Nil(t, err)
+	require.Equal(t, 5, inserted)
+
+	// A retried batch doesn't store the clicks again
+	inserted, err = clicks.ClickBatch(ctx, append(batch, urls.StoreClick{
+		ID:   url.ID,
+		IP:   "127.0.0.1",
+		Time: time.Now(),
+	}))
+	require.Nil(t, err)
+	require.Equal(t, 1, inserted)
+	require.Nil(t, clicks.Rollup(ctx, 0))
+
+	stats, err := clicks.Stats(ctx, url.ID)
+	require.Nil(t, err)
+	require.Equal(t, 6, stats.Clicks)
+}
+
+func TestItSkipsClicksForDeletedUrls(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	url := test.Url(t, b, test.UrlOpts{})
+	deleted := test.Url(t, b, test.UrlOpts{})
+	require.Nil(t, boiler.MustResolve[urls.Urls](b).Delete(ctx, deleted.ID))
+
+	clicks := boiler.MustResolve[*urls.Clicks](b)
+
+	require.Nil(t, clicks.Click(ctx, urls.StoreClick{
+		ID:   deleted.ID,
+		IP:   "127.0.0.1",
+		Time: time.Now(),
+	}))
+
+	inserted, err := clicks.ClickBatch(ctx, []urls.StoreClick{
+		{ID: url.ID, IP: "127.0.0.1", Time: time.Now()},
+		{ID: deleted.ID, IP: "127.0.0.1", Time: time.Now()},
+	})
+	require.Nil(t, err)
+	require.Equal(t, 1, inserted)
+}
+
+func TestItCountsClicksThatHaventBeenRolledUp(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	url := test.Url(t, b, test.UrlOpts{})
+
+	clicks := boiler.MustResolve[*urls.Clicks](b)
+
+	require.Nil(t, clicks.Click(ctx, urls.StoreClick{
+		ID:   url.ID,
+		IP:   "127.0.0.1",
+		Time: time.Now(),
+	}))
+	stats, err := clicks.Stats(ctx, url.ID)
+	require.Nil(t, err)
+	require.Equal(t, 1, stats.Clicks)
+
+	require.Nil(t, clicks.Rollup(ctx, 0))
+	stats, err = clicks.Stats(ctx, url.ID)
+	require.Nil(t, err)
+	require.Equal(t, 1, stats.Clicks)
+
+	// A click that arrives late is still rolled up
+	late := time.Now().Add(-time.Hour * 6)
+	require.Nil(t, clicks.CreatePartitions(ctx, late, time.Now()))
+	require.Nil(t, clicks.Click(ctx, urls.StoreClick{
+		ID:   url.ID,
+		IP:   "127.0.0.2",
+		Time: late,
+	}))
+	require.Nil(t, clicks.Rollup(ctx, 0))
+
+	stats, err = clicks.Stats(ctx, url.ID)
+	require.Nil(t, err)
+	require.Equal(t, 2, stats.Clicks)
+
+	series, err := clicks.Series(ctx, urls.SeriesParams{
+		ID:          url.ID,
+		Granularity: urls.Hour,
+		Since:       late,
+		Until:       time.Now().Add(time.Minute),
+	})
+	require.Nil(t, err)
+	total := 0
+	for _, bucket := range series {
+		total += bucket.Clicks
+	}
+	require.Equal(t, 2, total)
+}
@@ -3,19 +3,33 @@ package urls
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/henrywhitaker3/shorturl/internal/logger"
 	"github.com/henrywhitaker3/shorturl/internal/queue"
+	"github.com/henrywhitaker3/shorturl/internal/screening"
 	"github.com/hibiken/asynq"
 )
 
 type CreateJobHandler struct {
-	svc Urls
+	svc      Urls
+	statuses *Statuses
+	screener *screening.Screener
+	alias    *Alias
 }
 
-func NewCreateJobHandler(svc Urls) *CreateJobHandler {
+func NewCreateJobHandler(
+	svc Urls,
+	statuses *Statuses,
+	screener *screening.Screener,
+	alias *Alias,
+) *CreateJobHandler {
 	return &CreateJobHandler{
-		svc: svc,
+		svc:      svc,
+		statuses: statuses,
+		screener: screener,
+		alias:    alias,
 	}
 }
 
@@ -25,11 +39,143 @@ func (c *CreateJobHandler) Handle(ctx context.Context, payload []byte) error {
 		return fmt.Errorf("unmarhsal job: %w %w", err, asynq.SkipRetry)
 	}
 
+	// The blocklist may have changed since the url was requested
+	if err := c.screener.Screen(ctx, job.Url); err != nil {
+		blocked := errors.Is(err, screening.ErrBlocked)
+		final := blocked || finalAttempt(ctx, err)
+		if serr := c.statuses.Failed(ctx, job.ID, err, final); serr != nil {
+			logger.Logger(ctx).Error("could not store create status", "error", serr)
+		}
+		if final {
+			releaseAlias(ctx, c.alias, job.Alias)
+		}
+		if blocked {
+			return fmt.Errorf("%w %w", err, asynq.SkipRetry)
+		}
+		return err
+	}
+
 	_, err := c.svc.Create(ctx, CreateParams{
-		ID:     job.ID,
-		Url:    job.Url,
-		Domain: job.Domain,
+		ID:        job.ID,
+		Url:       job.Url,
+		Domain:    job.Domain,
+		Alias:     job.Alias,
+		ExpiresAt: job.ExpiresAt,
+		MaxClicks: job.MaxClicks,
+		Owner:     job.Owner,
 	})
+	if err != nil {
+		// The status is only failed once asynq gives up on the job
+		final := finalAttempt(ctx, err)
+		if serr := c.statuses.Failed(ctx, job.ID, err, final); serr != nil {
+			logger.Logger(ctx).Error("could not store create status", "error", serr)
+		}
+		if final {
+			releaseAlias(ctx, c.alias, job.Alias)
+		}
+		return err
+	}
+
+	// The url has been stored, so don't fail the job and create it again
+	if err := c.statuses.Created(ctx, job.ID); err != nil {
+		logger.Logger(ctx).Error("could not store create status", "error", err)
+	}
+
+	return nil
+}
+
+type CreateBatchJobHandler struct {
+	svc      Urls
+	statuses *Statuses
+	screener *screening.Screener
+	alias    *Alias
+}
+
+func NewCreateBatchJobHandler(
+	svc Urls,
+	statuses *Statuses,
+	screener *screening.Screener,
+	alias *Alias,
+) *CreateBatchJobHandler {
+	return &CreateBatchJobHandler{
+		svc:      svc,
+		statuses: statuses,
+		screener: screener,
+		alias:    alias,
+	}
+}
+
+func (c *CreateBatchJobHandler) Handle(ctx context.Context, payload []byte) error {
+	job := queue.CreateBatchJob{}
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("unmarhsal job: %w %w", err, asynq.SkipRetry)
+	}
+
+	params := []CreateParams{}
+	allowed := []queue.CreateJob{}
+	for _, url := range job.Urls {
+		// Blocked urls fail on their own rather than failing the batch
+		if err := c.screener.Screen(ctx, url.Url); err != nil {
+			if !errors.Is(err, screening.ErrBlocked) {
+				return err
+			}
+			if serr := c.statuses.Failed(ctx, url.ID, err, true); serr != nil {
+				logger.Logger(ctx).Error("could not store create status", "error", serr)
+			}
+			releaseAlias(ctx, c.alias, url.Alias)
+			continue
+		}
+		allowed = append(allowed, url)
+		params = append(params, CreateParams{
+			ID:        url.ID,
+			Url:       url.Url,
+			Domain:    url.Domain,
+			Alias:     url.Alias,
+			ExpiresAt: url.ExpiresAt,
+			MaxClicks: url.MaxClicks,
+			Owner:     url.Owner,
+		})
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+
+	// The urls are created in one transaction, so they all succeed or fail
+	_, err := c.svc.CreateBatch(ctx, params)
+	final := err != nil && finalAttempt(ctx, err)
+	for _, url := range allowed {
+		var serr error
+		if err != nil {
+			serr = c.statuses.Failed(ctx, url.ID, err, final)
+			if final {
+				releaseAlias(ctx, c.alias, url.Alias)
+			}
+		} else {
+			serr = c.statuses.Created(ctx, url.ID)
+		}
+		if serr != nil {
+			logger.Logger(ctx).Error("could not store create status", "error", serr)
+		}
+	}
 
 	return err
 }
+
+// Releases a claimed vanity alias once its url won't be created, so it can
+// be used again
+func releaseAlias(ctx context.Context, alias *Alias, name string) {
+	if name == "" {
+		return
+	}
+	if err := alias.Release(ctx, name); err != nil {
+		logger.Logger(ctx).Error("could not release alias", "alias", name, "error", err)
+	}
+}
+
+// Whether asynq will give up on the job after this attempt
+func finalAttempt(ctx context.Context, err error) bool {
+	retried, _ := asynq.GetRetryCount(ctx)
+	max, _ := asynq.GetMaxRetry(ctx)
+	return errors.Is(err, asynq.SkipRetry) || retried >= max
+}
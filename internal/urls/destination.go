@@ -0,0 +1,110 @@
+package urls
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	MaxDestinationLength = 2048
+)
+
+var (
+	ErrInvalidDestination = errors.New("invalid url")
+
+	AllowedSchemes = []string{"http", "https"}
+
+	defaultPorts = map[string]string{
+		"http":  "80",
+		"https": "443",
+	}
+)
+
+// Validates a destination url and returns it in canonical form, with a
+// lowercase punycode host and without the scheme's default port
+func NormaliseDestination(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > MaxDestinationLength {
+		return "", fmt.Errorf(
+			"%w: must be at most %d characters",
+			ErrInvalidDestination,
+			MaxDestinationLength,
+		)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: could not be parsed", ErrInvalidDestination)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if !slices.Contains(AllowedSchemes, u.Scheme) {
+		return "", fmt.Errorf(
+			"%w: scheme must be one of %s",
+			ErrInvalidDestination,
+			strings.Join(AllowedSchemes, ", "),
+		)
+	}
+	if u.Opaque != "" || u.Host == "" {
+		return "", fmt.Errorf("%w: must have a host", ErrInvalidDestination)
+	}
+	// Credentials are mostly used to disguise the real host
+	if u.User != nil {
+		return "", fmt.Errorf("%w: must not contain credentials", ErrInvalidDestination)
+	}
+
+	host, err := normaliseHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+	port := u.Port()
+	if port != "" {
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return "", fmt.Errorf("%w: invalid port", ErrInvalidDestination)
+		}
+		if defaultPorts[u.Scheme] == port {
+			port = ""
+		}
+	}
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	out := u.String()
+	// Converting the host to punycode can make it longer
+	if len(out) > MaxDestinationLength {
+		return "", fmt.Errorf(
+			"%w: must be at most %d characters",
+			ErrInvalidDestination,
+			MaxDestinationLength,
+		)
+	}
+	return out, nil
+}
+
+func normaliseHost(host string) (string, error) {
+	if host == "" {
+		return "", fmt.Errorf("%w: must have a host", ErrInvalidDestination)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+	ascii, err := idna.Lookup.ToASCII(strings.TrimSuffix(host, "."))
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid host", ErrInvalidDestination)
+	}
+	if !strings.Contains(ascii, ".") && ascii != "localhost" {
+		return "", fmt.Errorf("%w: host must be a domain or ip address", ErrInvalidDestination)
+	}
+	return strings.ToLower(ascii), nil
+}
@@ -0,0 +1,48 @@
+package urls_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItNormalisesDestinations(t *testing.T) {
+	tcs := []struct {
+		name string
+		in   string
+		out  string
+		err  bool
+	}{
+		{name: "leaves a canonical url", in: "https://example.com/path?q=1#frag", out: "https://example.com/path?q=1#frag"},
+		{name: "lowercases the scheme and host", in: "HTTPS://EXAMPLE.com/Path", out: "https://example.com/Path"},
+		{name: "strips the default http port", in: "http://example.com:80/", out: "http://example.com/"},
+		{name: "strips the default https port", in: "https://example.com:443", out: "https://example.com"},
+		{name: "keeps other ports", in: "https://example.com:8443/", out: "https://example.com:8443/"},
+		{name: "converts idn hosts to punycode", in: "https://bücher.example/", out: "https://xn--bcher-kva.example/"},
+		{name: "accepts ipv4 hosts", in: "http://127.0.0.1:8080/", out: "http://127.0.0.1:8080/"},
+		{name: "accepts ipv6 hosts", in: "http://[::1]:80/", out: "http://[::1]/"},
+		{name: "trims whitespace", in: "  https://example.com  ", out: "https://example.com"},
+		{name: "rejects javascript", in: "javascript:alert(1)", err: true},
+		{name: "rejects relative paths", in: "/some/path", err: true},
+		{name: "rejects garbage", in: "bongo", err: true},
+		{name: "rejects other schemes", in: "ftp://example.com", err: true},
+		{name: "rejects credentials", in: "https://google.com@example.com", err: true},
+		{name: "rejects invalid ports", in: "https://example.com:99999", err: true},
+		{name: "rejects single label hosts", in: "https://bongo/", err: true},
+		{name: "rejects long urls", in: "https://example.com/" + strings.Repeat("a", urls.MaxDestinationLength), err: true},
+	}
+
+	for _, c := range tcs {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := urls.NormaliseDestination(c.in)
+			if c.err {
+				require.ErrorIs(t, err, urls.ErrInvalidDestination)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, c.out, out)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package urls
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/workers"
+)
+
+type Expiry struct {
+	urls    Urls
+	enabled bool
+	period  time.Duration
+}
+
+type ExpiryOpts struct {
+	Urls   Urls
+	Config config.Sweep
+}
+
+func NewExpiry(opts ExpiryOpts) *Expiry {
+	return &Expiry{
+		urls:    opts.Urls,
+		enabled: opts.Config.Enabled,
+		period:  opts.Config.Period,
+	}
+}
+
+func (e *Expiry) Name() string {
+	return "expiry"
+}
+
+func (e *Expiry) Timeout() time.Duration {
+	return time.Second * 30
+}
+
+func (e *Expiry) Interval() workers.Interval {
+	return workers.NewInterval(time.Minute)
+}
+
+func (e *Expiry) Run(ctx context.Context) error {
+	if !e.enabled {
+		return nil
+	}
+
+	deleted, err := e.urls.DeleteExpired(ctx, time.Now().Add(-e.period))
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		slog.Info("deleted expired urls", "count", deleted)
+	}
+
+	return nil
+}
+
+var _ workers.Worker = &Expiry{}
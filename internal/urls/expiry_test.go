@@ -0,0 +1,68 @@
+package urls_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItSweepsExpiredUrls(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	expires := time.Now().Add(time.Second)
+	expired := test.Url(t, b, test.UrlOpts{
+		ExpiresAt: &expires,
+	})
+	live := test.Url(t, b, test.UrlOpts{})
+
+	time.Sleep(time.Until(expires) + time.Second)
+
+	svc := boiler.MustResolve[urls.Urls](b)
+	expiry := urls.NewExpiry(urls.ExpiryOpts{
+		Urls: svc,
+		Config: config.Sweep{
+			Enabled: true,
+			Period:  0,
+		},
+	})
+	require.Nil(t, expiry.Run(ctx))
+
+	_, err := svc.Get(ctx, expired.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = svc.Get(ctx, live.ID)
+	require.Nil(t, err)
+}
+
+func TestItDoesntReportDeletedUrlsAsExpired(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	svc := boiler.MustResolve[urls.Urls](b)
+	max := 2
+	url := test.Url(t, b, test.UrlOpts{MaxClicks: &max})
+
+	require.Nil(t, svc.Delete(ctx, url.ID))
+
+	err := svc.Visit(ctx, url)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NotErrorIs(t, err, urls.ErrExpired)
+
+	exhausted := test.Url(t, b, test.UrlOpts{MaxClicks: &max})
+	for range max {
+		require.Nil(t, svc.Visit(ctx, exhausted))
+	}
+	require.ErrorIs(t, svc.Visit(ctx, exhausted), urls.ErrExpired)
+}
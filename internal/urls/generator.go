@@ -2,13 +2,15 @@ package urls
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"log/slog"
 	"math"
-	"math/rand"
+	"strings"
 	"time"
 
+	"github.com/henrywhitaker3/shorturl/internal/config"
 	"github.com/henrywhitaker3/shorturl/internal/workers"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -21,39 +23,72 @@ type AliasGeneratorOpts struct {
 	// The interval the generator fills the buffer
 	Interval time.Duration
 
-	// The length of the alias
+	// The length of the alias to start generating at
 	Length int
+	// The fraction of the aliases of the current length that can be used
+	// before moving to the next length (default: 0.5)
+	GrowThreshold float64
+	// The characters aliases are made from, at most 256 (default: base36)
+	Alphabet string
 
 	Registry prometheus.Registerer
 }
 
 type AliasGenerator struct {
-	alias      *Alias
-	size       int
-	interval   time.Duration
-	length     int
-	logger     *slog.Logger
+	alias     *Alias
+	size      int
+	interval  time.Duration
+	length    int
+	threshold float64
+	alphabet  string
+	logger    *slog.Logger
+
 	generated  prometheus.Counter
 	collisions prometheus.Counter
+	remaining  prometheus.Gauge
+	current    prometheus.Gauge
+	switches   prometheus.Counter
 }
 
 func NewAliasGenerator(opts AliasGeneratorOpts) *AliasGenerator {
 	if opts.BufferSize == 0 {
 		opts.BufferSize = 10000
 	}
+	if opts.Alphabet == "" {
+		opts.Alphabet = config.Base36Alphabet
+	}
+	if opts.GrowThreshold == 0 {
+		opts.GrowThreshold = 0.5
+	}
 	gen := &AliasGenerator{
-		alias:    opts.Alias,
-		size:     opts.BufferSize,
-		interval: opts.Interval,
-		length:   opts.Length,
-		logger:   slog.Default().With("subsystem", "generator"),
+		alias:     opts.Alias,
+		size:      opts.BufferSize,
+		interval:  opts.Interval,
+		length:    opts.Length,
+		threshold: opts.GrowThreshold,
+		alphabet:  opts.Alphabet,
+		logger:    slog.Default().With("subsystem", "generator"),
 		generated: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "generator_generated",
 		}),
 		collisions: prometheus.NewCounter(prometheus.CounterOpts{
-			Name: "generator_collisiont_total",
+			Name: "generator_collisions_total",
+			Help: "The number of generated aliases that were already taken",
+		}),
+		remaining: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "generator_keyspace_remaining",
+			Help: "The number of aliases of the current length that haven't been generated",
+		}),
+		current: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "generator_alias_length",
+			Help: "The length of the aliases being generated",
+		}),
+		switches: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "generator_length_switches_total",
+			Help: "The number of times the generator moved to longer aliases",
 		}),
 	}
+	gen.current.Set(float64(gen.length))
 
 	if opts.Registry != nil {
 		if err := opts.Registry.Register(gen.generated); err != nil {
@@ -62,6 +97,15 @@ func NewAliasGenerator(opts AliasGeneratorOpts) *AliasGenerator {
 		if err := opts.Registry.Register(gen.collisions); err != nil {
 			gen.logger.Error("failed to register metric", "metric", "collisions")
 		}
+		if err := opts.Registry.Register(gen.remaining); err != nil {
+			gen.logger.Error("failed to register metric", "metric", "keyspace_remaining")
+		}
+		if err := opts.Registry.Register(gen.current); err != nil {
+			gen.logger.Error("failed to register metric", "metric", "alias_length")
+		}
+		if err := opts.Registry.Register(gen.switches); err != nil {
+			gen.logger.Error("failed to register metric", "metric", "length_switches")
+		}
 	}
 
 	return gen
@@ -79,10 +123,15 @@ func (a *AliasGenerator) Timeout() time.Duration {
 	return time.Minute
 }
 
+// The length of the aliases currently being generated
+func (a *AliasGenerator) Length() int {
+	return a.length
+}
+
 func (a *AliasGenerator) Run(ctx context.Context) error {
 	a.logger.Debug("filling up url buffer")
 
-	aliases, err := a.generateAliases(ctx)
+	aliases, used, err := a.generateAliases(ctx)
 	if err != nil {
 		return fmt.Errorf("generate aliases: %w", err)
 	}
@@ -105,91 +154,114 @@ func (a *AliasGenerator) Run(ctx context.Context) error {
 		generated++
 	}
 
+	a.generated.Add(float64(generated))
+	a.remaining.Set(keyspace(a.alphabet, a.length) - float64(used+generated))
 	a.logger.Info("filled up buffer", "count", generated)
 
 	return nil
 }
 
-func (a *AliasGenerator) generateAliases(ctx context.Context) ([]string, error) {
+// Generates the aliases needed to fill up the buffer, returning them with the
+// number of aliases of their length that already exist
+func (a *AliasGenerator) generateAliases(ctx context.Context) ([]string, int, error) {
 	free, err := a.alias.CountFree(ctx)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	if free >= a.size {
-		return []string{}, nil
+		return []string{}, 0, nil
 	}
 
 	toGenerate := a.size - free
 	if toGenerate < 1 {
-		return []string{}, nil
+		return []string{}, 0, nil
+	}
+
+	used, err := a.grow(ctx)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	a.logger.Debug("generating aliases", "length", a.length, "count", toGenerate)
+	seen := map[string]struct{}{}
 	aliases := []string{}
 	for range toGenerate {
-		aliases = append(aliases, generateAlias(a.length))
+		alias, err := generateAlias(a.alphabet, a.length)
+		if err != nil {
+			return nil, 0, fmt.Errorf("generate alias: %w", err)
+		}
+		if _, ok := seen[alias]; ok {
+			a.collisions.Inc()
+			continue
+		}
+		seen[alias] = struct{}{}
+		aliases = append(aliases, alias)
 	}
 
 	filtered, err := a.alias.FilterOutExisting(ctx, aliases)
 	if err != nil {
-		return nil, fmt.Errorf("could not filter existing alises out of generated: %w", err)
+		return nil, 0, fmt.Errorf("could not filter existing alises out of generated: %w", err)
 	}
+	a.collisions.Add(float64(len(aliases) - len(filtered)))
 
-	return filtered, nil
+	return filtered, used, nil
 }
 
-func generateAlias(length int) string {
-	out := ""
-	for range length {
-		out += chars[rand.Intn(len(chars))]
+// Moves to longer aliases while the share of the current length that is used
+// is past the threshold, as more and more generated aliases would collide.
+// Returns the number of aliases of the length that are used.
+func (a *AliasGenerator) grow(ctx context.Context) (int, error) {
+	for {
+		used, err := a.alias.CountWithLength(ctx, a.length)
+		if err != nil {
+			return 0, err
+		}
+		utilisation := float64(used) / keyspace(a.alphabet, a.length)
+		if utilisation < a.threshold || a.length >= MaxAliasLength {
+			return used, nil
+		}
+
+		a.logger.Warn(
+			"keyspace utilisation past threshold, increasing alias length",
+			"length", a.length+1,
+			"utilisation", utilisation,
+			"threshold", a.threshold,
+		)
+		a.length++
+		a.current.Set(float64(a.length))
+		a.switches.Inc()
 	}
-	return out
 }
 
-var (
-	chars = []string{
-		"a",
-		"b",
-		"c",
-		"d",
-		"e",
-		"f",
-		"g",
-		"h",
-		"i",
-		"j",
-		"k",
-		"l",
-		"m",
-		"n",
-		"o",
-		"p",
-		"q",
-		"r",
-		"s",
-		"t",
-		"u",
-		"v",
-		"w",
-		"x",
-		"y",
-		"z",
-		"0",
-		"1",
-		"2",
-		"3",
-		"4",
-		"5",
-		"6",
-		"7",
-		"8",
-		"9",
-		"0",
-	}
-	fourChar = int(math.Pow(float64(len(chars)), 4))
-	fiveChar = int(math.Pow(float64(len(chars)), 5))
-	sixChar  = int(math.Pow(float64(len(chars)), 6))
-)
+// Generates a random alias from the alphabet. Random bytes are only used when
+// they are below the largest multiple of the alphabet size, so every character
+// is equally likely.
+func generateAlias(alphabet string, length int) (string, error) {
+	limit := 256 - 256%len(alphabet)
+	out := strings.Builder{}
+	out.Grow(length)
+	buf := make([]byte, length+length/2)
+	for out.Len() < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			out.WriteByte(alphabet[int(b)%len(alphabet)])
+			if out.Len() == length {
+				break
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+// The number of aliases of the length that can be generated from the alphabet
+func keyspace(alphabet string, length int) float64 {
+	return math.Pow(float64(len(alphabet)), float64(length))
+}
 
 var _ workers.Worker = &AliasGenerator{}
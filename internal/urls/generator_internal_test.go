@@ -0,0 +1,35 @@
+package urls
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItGeneratesAliasesFromTheAlphabet(t *testing.T) {
+	for _, alphabet := range []string{
+		config.Base36Alphabet,
+		config.Base62Alphabet,
+		"abcdefghjkmnpqrstuvwxyz23456789",
+	} {
+		counts := map[rune]int{}
+		for range 5000 {
+			alias, err := generateAlias(alphabet, 6)
+			require.Nil(t, err)
+			require.Len(t, alias, 6)
+			for _, char := range alias {
+				require.True(t, strings.ContainsRune(alphabet, char))
+				counts[char]++
+			}
+		}
+
+		// Every character should be picked close to the same number of times
+		expected := 5000 * 6 / len(alphabet)
+		require.Len(t, counts, len(alphabet))
+		for char, count := range counts {
+			require.InDelta(t, expected, count, float64(expected)/4, "character %q", char)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package urls_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItGrowsTheAliasLengthPastTheThreshold(t *testing.T) {
+	// A new database so there aren't already enough free aliases
+	b := test.Boiler(t, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	alias := boiler.MustResolve[*urls.Alias](b)
+	gen := urls.NewAliasGenerator(urls.AliasGeneratorOpts{
+		Alias:         alias,
+		BufferSize:    4,
+		Length:        2,
+		Alphabet:      "xy",
+		GrowThreshold: 0.5,
+	})
+
+	// Uses 2 of the 4 aliases with 2 characters
+	require.Nil(t, alias.Create(ctx, "xy"))
+	require.Nil(t, alias.Create(ctx, "yx"))
+
+	require.Nil(t, gen.Run(ctx))
+	require.Equal(t, 3, gen.Length())
+
+	count, err := alias.CountWithLength(ctx, 3)
+	require.Nil(t, err)
+	require.Greater(t, count, 0)
+	count, err = alias.CountWithLength(ctx, 2)
+	require.Nil(t, err)
+	require.Equal(t, 2, count)
+}
@@ -0,0 +1,154 @@
+package urls
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/redis/rueidis"
+)
+
+type Window string
+
+const (
+	WindowHour Window = "1h"
+	WindowDay  Window = "1d"
+	WindowWeek Window = "7d"
+)
+
+func (w Window) Valid() bool {
+	return w == WindowHour || w == WindowDay || w == WindowWeek
+}
+
+func (w Window) Duration() time.Duration {
+	switch w {
+	case WindowHour:
+		return time.Hour
+	case WindowDay:
+		return time.Hour * 24
+	default:
+		return time.Hour * 24 * 7
+	}
+}
+
+// The size of the buckets the window is counted in. Windows are made up of
+// whole buckets, including the current one, so they can be up to a bucket
+// shorter than their duration.
+func (w Window) resolution() time.Duration {
+	if w == WindowHour {
+		return time.Minute
+	}
+	return time.Hour
+}
+
+// Scope for the clicks on every url, used when auth is disabled
+const allScope = "all"
+
+// Keeps counts of recent clicks per url in redis sorted sets, bucketed by
+// minute and hour
+type Leaderboard struct {
+	redis rueidis.Client
+}
+
+type LeaderboardOpts struct {
+	Redis rueidis.Client
+}
+
+func NewLeaderboard(opts LeaderboardOpts) *Leaderboard {
+	return &Leaderboard{redis: opts.Redis}
+}
+
+// The keys for a scope share a hash tag so they can be merged in a cluster
+func leaderboardKey(scope string, resolution time.Duration, bucket time.Time) string {
+	return fmt.Sprintf("top:{%s}:%s:%d", scope, resolution, bucket.Unix())
+}
+
+// Counts a click on the url, for the leaderboard of every url and of the
+// url's owner when it is not nil
+func (l *Leaderboard) Click(ctx context.Context, id uuid.UUID, owner *uuid.UUID, at time.Time) error {
+	scopes := []string{allScope}
+	if owner != nil {
+		scopes = append(scopes, owner.String())
+	}
+
+	cmds := rueidis.Commands{}
+	for _, scope := range scopes {
+		for _, res := range []struct {
+			size time.Duration
+			ttl  time.Duration
+		}{
+			{size: time.Minute, ttl: WindowHour.Duration()},
+			{size: time.Hour, ttl: WindowWeek.Duration()},
+		} {
+			key := leaderboardKey(scope, res.size, at.UTC().Truncate(res.size))
+			cmds = append(
+				cmds,
+				l.redis.B().Zincrby().Key(key).Increment(1).Member(id.String()).Build(),
+				l.redis.B().Expire().Key(key).Seconds(int64((res.ttl + res.size).Seconds())).Build(),
+			)
+		}
+	}
+	for _, resp := range l.redis.DoMulti(ctx, cmds...) {
+		if err := resp.Error(); err != nil {
+			return fmt.Errorf("could not count click: %w", err)
+		}
+	}
+	return nil
+}
+
+type Ranked struct {
+	ID     uuid.UUID
+	Clicks int
+}
+
+// Returns the most clicked urls in the window, most clicked first, skipping
+// the first offset urls. Only the urls created by the owner are included
+// when it is not nil.
+func (l *Leaderboard) Top(
+	ctx context.Context,
+	owner *uuid.UUID,
+	window Window,
+	offset int,
+	limit int,
+) ([]*Ranked, error) {
+	scope := allScope
+	if owner != nil {
+		scope = owner.String()
+	}
+
+	res := window.resolution()
+	now := time.Now().UTC().Truncate(res)
+	keys := []string{}
+	for bucket := now.Add(-window.Duration()).Add(res); !bucket.After(now); bucket = bucket.Add(res) {
+		keys = append(keys, leaderboardKey(scope, res, bucket))
+	}
+
+	dest := fmt.Sprintf("top:{%s}:merge:%s", scope, uuid.MustOrdered())
+	resps := l.redis.DoMulti(
+		ctx,
+		l.redis.B().Zunionstore().Destination(dest).Numkeys(int64(len(keys))).Key(keys...).Build(),
+		l.redis.B().Zrange().Key(dest).Min(strconv.Itoa(offset)).Max(strconv.Itoa(offset+limit-1)).Rev().Withscores().Build(),
+		l.redis.B().Del().Key(dest).Build(),
+	)
+	for _, resp := range resps {
+		if err := resp.Error(); err != nil {
+			return nil, fmt.Errorf("could not get top urls: %w", err)
+		}
+	}
+	scores, err := resps[1].AsZScores()
+	if err != nil {
+		return nil, fmt.Errorf("could not get top urls: %w", err)
+	}
+
+	out := make([]*Ranked, 0, len(scores))
+	for _, score := range scores {
+		id, err := uuid.Parse(score.Member)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse url id: %w", err)
+		}
+		out = append(out, &Ranked{ID: id, Clicks: int(score.Score)})
+	}
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package urls_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItRanksTheMostClickedUrls(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	top := boiler.MustResolve[*urls.Leaderboard](b)
+	owner := uuid.MustNew()
+
+	first := &urls.Url{ID: uuid.MustNew(), OwnerID: &owner}
+	second := &urls.Url{ID: uuid.MustNew(), OwnerID: &owner}
+	other := &urls.Url{ID: uuid.MustNew()}
+
+	now := time.Now()
+	for range 3 {
+		require.Nil(t, top.Click(ctx, first.ID, first.OwnerID, now))
+	}
+	require.Nil(t, top.Click(ctx, second.ID, second.OwnerID, now))
+	// Only in the day and week windows
+	require.Nil(t, top.Click(ctx, second.ID, second.OwnerID, now.Add(-time.Hour*2)))
+	require.Nil(t, top.Click(ctx, second.ID, second.OwnerID, now.Add(-time.Hour*2)))
+	require.Nil(t, top.Click(ctx, second.ID, second.OwnerID, now.Add(-time.Hour*2)))
+	require.Nil(t, top.Click(ctx, other.ID, other.OwnerID, now))
+
+	ranked, err := top.Top(ctx, &owner, urls.WindowHour, 0, 10)
+	require.Nil(t, err)
+	require.Len(t, ranked, 2)
+	require.Equal(t, first.ID, ranked[0].ID)
+	require.Equal(t, 3, ranked[0].Clicks)
+	require.Equal(t, second.ID, ranked[1].ID)
+	require.Equal(t, 1, ranked[1].Clicks)
+
+	ranked, err = top.Top(ctx, &owner, urls.WindowDay, 0, 1)
+	require.Nil(t, err)
+	require.Len(t, ranked, 1)
+	require.Equal(t, second.ID, ranked[0].ID)
+	require.Equal(t, 4, ranked[0].Clicks)
+
+	ranked, err = top.Top(ctx, &owner, urls.WindowDay, 1, 1)
+	require.Nil(t, err)
+	require.Len(t, ranked, 1)
+	require.Equal(t, first.ID, ranked[0].ID)
+
+	// Every url is included without an owner
+	ranked, err = top.Top(ctx, nil, urls.WindowWeek, 0, 10)
+	require.Nil(t, err)
+	ids := []uuid.UUID{}
+	for _, r := range ranked {
+		ids = append(ids, r.ID)
+	}
+	require.Contains(t, ids, other.ID)
+	require.Contains(t, ids, first.ID)
+}
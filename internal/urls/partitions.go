@@ -0,0 +1,47 @@
+package urls
+
+import (
+	"context"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/workers"
+)
+
+// Creates the daily clicks partitions ahead of time, so clicks don't end up
+// in the default partition
+type Partitions struct {
+	clicks *Clicks
+	ahead  time.Duration
+}
+
+type PartitionsOpts struct {
+	Clicks *Clicks
+	Config config.Partitions
+}
+
+func NewPartitions(opts PartitionsOpts) *Partitions {
+	return &Partitions{
+		clicks: opts.Clicks,
+		ahead:  opts.Config.Ahead,
+	}
+}
+
+func (p *Partitions) Name() string {
+	return "partitions"
+}
+
+func (p *Partitions) Timeout() time.Duration {
+	return time.Minute
+}
+
+func (p *Partitions) Interval() workers.Interval {
+	return workers.NewInterval(time.Hour)
+}
+
+func (p *Partitions) Run(ctx context.Context) error {
+	now := time.Now()
+	return p.clicks.CreatePartitions(ctx, now, now.Add(p.ahead))
+}
+
+var _ workers.Worker = &Partitions{}
@@ -5,26 +5,31 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/henrywhitaker3/shorturl/internal/archive"
 	"github.com/henrywhitaker3/shorturl/internal/config"
 	"github.com/henrywhitaker3/shorturl/internal/workers"
 )
 
 type Retention struct {
-	clicks  *Clicks
-	enabled bool
-	period  time.Duration
+	clicks   *Clicks
+	archiver *archive.Archiver
+	enabled  bool
+	period   time.Duration
 }
 
 type RetentionOpts struct {
 	Clicks *Clicks
-	Config config.Retention
+	// Clicks are archived before they are deleted when set
+	Archiver *archive.Archiver
+	Config   config.Retention
 }
 
 func NewRetention(opts RetentionOpts) *Retention {
 	return &Retention{
-		clicks:  opts.Clicks,
-		enabled: opts.Config.Enabled,
-		period:  opts.Config.Period,
+		clicks:   opts.Clicks,
+		archiver: opts.Archiver,
+		enabled:  opts.Config.Enabled,
+		period:   opts.Config.Period,
 	}
 }
 
@@ -33,6 +38,9 @@ func (r *Retention) Name() string {
 }
 
 func (r *Retention) Timeout() time.Duration {
+	if r.archiver != nil {
+		return time.Minute * 10
+	}
 	return time.Second * 30
 }
 
@@ -45,12 +53,27 @@ func (r *Retention) Run(ctx context.Context) error {
 		return nil
 	}
 
-	deleted, err := r.clicks.Delete(ctx, time.Now().Add(-r.period))
+	// Clicks are deleted a whole day at a time
+	cutoff := time.Now().Add(-r.period).UTC().Truncate(time.Hour * 24)
+
+	if r.archiver != nil {
+		archived, err := r.archiver.Archive(ctx, cutoff)
+		if err != nil {
+			return err
+		}
+		if archived > 0 {
+			slog.Info("archived expired clicks", "days", archived)
+		}
+	}
+
+	dropped, err := r.clicks.Delete(ctx, cutoff)
 	if err != nil {
 		return err
 	}
 
-	slog.Info("deleted click data", "count", deleted)
+	if dropped > 0 {
+		slog.Info("dropped expired click partitions", "count", dropped)
+	}
 
 	return nil
 }
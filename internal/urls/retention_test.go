@@ -26,16 +26,19 @@ func TestItDeletesClick(t *testing.T) {
 	require.Nil(t, err)
 	require.Zero(t, stats.Clicks)
 
+	// Clicks are dropped a whole day at a time
+	require.Nil(t, clicks.CreatePartitions(ctx, time.Now().Add(-time.Hour*72), time.Now()))
 	require.Nil(t, clicks.Click(ctx, urls.StoreClick{
 		ID:   url.ID,
 		IP:   "127.0.0.1",
-		Time: time.Now().Add(-time.Hour),
+		Time: time.Now().Add(-time.Hour * 72),
 	}))
 	require.Nil(t, clicks.Click(ctx, urls.StoreClick{
 		ID:   url.ID,
 		IP:   "127.0.0.1",
 		Time: time.Now(),
 	}))
+	require.Nil(t, clicks.Rollup(ctx, 0))
 
 	stats, err = clicks.Stats(ctx, url.ID)
 	require.Nil(t, err)
@@ -45,12 +48,26 @@ func TestItDeletesClick(t *testing.T) {
 		Clicks: clicks,
 		Config: config.Retention{
 			Enabled: true,
-			Period:  time.Minute,
+			Period:  time.Hour * 48,
 		},
 	})
 	require.Nil(t, retention.Run(ctx))
 
+	raw, err := clicks.Series(ctx, urls.SeriesParams{
+		ID:          url.ID,
+		Granularity: urls.Minute,
+		Since:       time.Now().Add(-time.Hour * 73),
+		Until:       time.Now().Add(time.Minute),
+	})
+	require.Nil(t, err)
+	total := 0
+	for _, bucket := range raw {
+		total += bucket.Clicks
+	}
+	require.Equal(t, 1, total)
+
+	// The rolled up clicks are kept
 	stats, err = clicks.Stats(ctx, url.ID)
 	require.Nil(t, err)
-	require.Equal(t, 1, stats.Clicks)
+	require.Equal(t, 2, stats.Clicks)
 }
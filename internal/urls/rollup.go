@@ -0,0 +1,48 @@
+package urls
+
+import (
+	"context"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/internal/config"
+	"github.com/henrywhitaker3/shorturl/internal/workers"
+)
+
+// Keeps the click rollups up to date, adding the clicks stored since the
+// last run
+type Rollup struct {
+	clicks   *Clicks
+	interval time.Duration
+	delay    time.Duration
+}
+
+type RollupOpts struct {
+	Clicks *Clicks
+	Config config.Rollup
+}
+
+func NewRollup(opts RollupOpts) *Rollup {
+	return &Rollup{
+		clicks:   opts.Clicks,
+		interval: opts.Config.Interval,
+		delay:    opts.Config.Delay,
+	}
+}
+
+func (r *Rollup) Name() string {
+	return "rollup"
+}
+
+func (r *Rollup) Timeout() time.Duration {
+	return time.Minute
+}
+
+func (r *Rollup) Interval() workers.Interval {
+	return workers.NewInterval(r.interval)
+}
+
+func (r *Rollup) Run(ctx context.Context) error {
+	return r.clicks.Rollup(ctx, r.delay)
+}
+
+var _ workers.Worker = &Rollup{}
@@ -0,0 +1,111 @@
+package urls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/internal/screening"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/henrywhitaker3/shorturl/internal/workers"
+)
+
+const (
+	screeningPageSize = 500
+)
+
+// Screens existing urls again so destinations that are blocked after the
+// url was created get quarantined, and quarantined urls that are no longer
+// blocked are released
+type Screening struct {
+	urls     Urls
+	screener *screening.Screener
+	interval time.Duration
+}
+
+type ScreeningOpts struct {
+	Urls     Urls
+	Screener *screening.Screener
+	Interval time.Duration
+}
+
+func NewScreening(opts ScreeningOpts) *Screening {
+	return &Screening{
+		urls:     opts.Urls,
+		screener: opts.Screener,
+		interval: opts.Interval,
+	}
+}
+
+func (s *Screening) Name() string {
+	return "screening"
+}
+
+func (s *Screening) Timeout() time.Duration {
+	return s.interval
+}
+
+func (s *Screening) Interval() workers.Interval {
+	return workers.NewInterval(s.interval)
+}
+
+func (s *Screening) Run(ctx context.Context) error {
+	if !s.screener.Enabled() {
+		return nil
+	}
+
+	quarantined, released := 0, 0
+	var cursor *uuid.UUID
+	for {
+		page, err := s.urls.List(ctx, ListParams{
+			Cursor: cursor,
+			Limit:  screeningPageSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, url := range page.Urls {
+			blocked := s.screener.Screen(ctx, url.Url)
+			if blocked != nil && !errors.Is(blocked, screening.ErrBlocked) {
+				return blocked
+			}
+
+			switch {
+			case blocked != nil && !url.Quarantined():
+				if _, err := s.urls.Quarantine(ctx, url.ID, blocked.Error()); err != nil {
+					// Quarantined or deleted since the page was listed
+					if errors.Is(err, sql.ErrNoRows) {
+						continue
+					}
+					return fmt.Errorf("quarantine url %s: %w", url.ID, err)
+				}
+				slog.Warn("quarantined url", "id", url.ID, "reason", blocked.Error())
+				quarantined++
+			case blocked == nil && url.Quarantined():
+				if _, err := s.urls.Unquarantine(ctx, url.ID); err != nil {
+					if errors.Is(err, sql.ErrNoRows) {
+						continue
+					}
+					return fmt.Errorf("unquarantine url %s: %w", url.ID, err)
+				}
+				slog.Info("released quarantined url", "id", url.ID)
+				released++
+			}
+		}
+
+		if page.Next == nil {
+			break
+		}
+		cursor = page.Next
+	}
+
+	slog.Info("screened urls", "quarantined", quarantined, "released", released)
+
+	return nil
+}
+
+var _ workers.Worker = &Screening{}
@@ -0,0 +1,87 @@
+package urls_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/screening"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItQuarantinesUrlsThatBecomeBlocked(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	domain := fmt.Sprintf("%s.com", strings.ToLower(test.Letters(12)))
+	blocked := test.Url(t, b, test.UrlOpts{Url: fmt.Sprintf("https://www.%s/login", domain)})
+	live := test.Url(t, b, test.UrlOpts{})
+
+	test.Blocklist(t, b, domain)
+
+	svc := boiler.MustResolve[urls.Urls](b)
+	worker := urls.NewScreening(urls.ScreeningOpts{
+		Urls:     svc,
+		Screener: boiler.MustResolve[*screening.Screener](b),
+		Interval: time.Minute,
+	})
+	require.Nil(t, worker.Run(ctx))
+
+	url, err := svc.Get(ctx, blocked.ID)
+	require.Nil(t, err)
+	require.True(t, url.Quarantined())
+	require.Contains(t, url.QuarantineReason, domain)
+
+	url, err = svc.Get(ctx, live.ID)
+	require.Nil(t, err)
+	require.False(t, url.Quarantined())
+}
+
+func TestItReleasesUrlsThatAreNoLongerBlocked(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	domain := fmt.Sprintf("%s.com", strings.ToLower(test.Letters(12)))
+	blocked := test.Url(t, b, test.UrlOpts{Url: fmt.Sprintf("https://%s", domain)})
+
+	path := filepath.Join(t.TempDir(), "blocklist")
+	require.Nil(t, os.WriteFile(path, []byte(domain), 0o644))
+	list, err := screening.NewBlocklist(path)
+	require.Nil(t, err)
+
+	svc := boiler.MustResolve[urls.Urls](b)
+	worker := urls.NewScreening(urls.ScreeningOpts{
+		Urls:     svc,
+		Screener: screening.New(list),
+		Interval: time.Minute,
+	})
+	require.Nil(t, worker.Run(ctx))
+
+	url, err := svc.Get(ctx, blocked.ID)
+	require.Nil(t, err)
+	require.True(t, url.Quarantined())
+
+	require.Nil(t, os.WriteFile(path, []byte("# nothing blocked"), 0o644))
+	later := time.Now().Add(time.Minute)
+	require.Nil(t, os.Chtimes(path, later, later))
+	reloaded, err := list.Reload()
+	require.Nil(t, err)
+	require.True(t, reloaded)
+	require.Nil(t, worker.Run(ctx))
+
+	url, err = svc.Get(ctx, blocked.ID)
+	require.Nil(t, err)
+	require.False(t, url.Quarantined())
+	require.Empty(t, url.QuarantineReason)
+}
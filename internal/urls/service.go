@@ -3,37 +3,70 @@ package urls
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/henrywhitaker3/shorturl/database/queries"
 	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/henrywhitaker3/shorturl/internal/webhooks"
+)
+
+var (
+	ErrExpired = errors.New("url has expired")
 )
 
 type Service struct {
-	db    *queries.Queries
-	conn  *sql.DB
-	alias *Alias
+	db       *queries.Queries
+	conn     *sql.DB
+	alias    *Alias
+	webhooks *webhooks.Webhooks
 }
 
 type ServiceOpts struct {
 	DB    *queries.Queries
 	Conn  *sql.DB
 	Alias *Alias
+	// Notified when urls are created, expire or are deleted, when not nil
+	Webhooks *webhooks.Webhooks
 }
 
 func New(opts ServiceOpts) *Service {
 	return &Service{
-		db:    opts.DB,
-		conn:  opts.Conn,
-		alias: opts.Alias,
+		db:       opts.DB,
+		conn:     opts.Conn,
+		alias:    opts.Alias,
+		webhooks: opts.Webhooks,
+	}
+}
+
+// Sends the event for each url to its owner's webhooks
+func (s *Service) notify(ctx context.Context, kind webhooks.EventType, urls ...*Url) {
+	if s.webhooks == nil || len(urls) == 0 {
+		return
+	}
+	events := make([]*webhooks.Event, 0, len(urls))
+	for _, url := range urls {
+		events = append(events, webhooks.NewEvent(kind, url.OwnerID, url))
 	}
+	s.webhooks.Dispatch(ctx, events...)
 }
 
 type CreateParams struct {
 	ID     uuid.UUID
 	Url    string
 	Domain string
+	// A vanity alias that has already been reserved using Alias.Claim,
+	// when empty a generated alias is taken from the buffer
+	Alias string
+	// The time after which the url stops redirecting
+	ExpiresAt *time.Time
+	// The number of visits after which the url stops redirecting
+	MaxClicks *int
+	// The api key that created the url
+	Owner *uuid.UUID
 }
 
 func (s *Service) Create(ctx context.Context, params CreateParams) (*Url, error) {
@@ -43,24 +76,34 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (*Url, error)
 	}
 	defer tx.Rollback()
 
-	aliasSvc := s.alias.WithTx(tx)
+	alias := params.Alias
+	if alias == "" {
+		aliasSvc := s.alias.WithTx(tx)
 
-	alias, err := aliasSvc.GetFree(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("could reserve free alias: %w", err)
+		alias, err = aliasSvc.GetFree(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could reserve free alias: %w", err)
+		}
+		slog.Debug("retieved free alias", "alias", alias)
+		slog.Debug("marking alias as used")
+		if err := aliasSvc.MarkUsed(ctx, alias); err != nil {
+			return nil, err
+		}
+	}
+
+	args := queries.CreateUrlParams{
+		ID:        params.ID.UUID(),
+		Alias:     alias,
+		Url:       params.Url,
+		Domain:    params.Domain,
+		ExpiresAt: nullTime(params.ExpiresAt),
+		MaxClicks: nullInt(params.MaxClicks),
 	}
-	slog.Debug("retieved free alias", "alias", alias)
-	slog.Debug("marking alias as used")
-	if err := aliasSvc.MarkUsed(ctx, alias); err != nil {
-		return nil, err
+	if params.Owner != nil {
+		args.OwnerID = params.Owner.NullUUID()
 	}
 
-	url, err := s.db.WithTx(tx).CreateUrl(ctx, queries.CreateUrlParams{
-		ID:     params.ID.UUID(),
-		Alias:  alias,
-		Url:    params.Url,
-		Domain: params.Domain,
-	})
+	url, err := s.db.WithTx(tx).CreateUrl(ctx, args)
 	if err != nil {
 		return nil, fmt.Errorf("store url: %w", err)
 	}
@@ -70,12 +113,65 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (*Url, error)
 		return nil, fmt.Errorf("commit insert url: %w", err)
 	}
 
-	return mapUrl(&queries.Url{
-		ID:     url.ID,
-		Alias:  url.Alias,
-		Url:    url.Url,
-		Domain: url.Domain,
-	}), nil
+	out := mapUrl(url)
+	s.notify(ctx, webhooks.UrlCreated, out)
+	return out, nil
+}
+
+// Creates all the urls in a single transaction, generated aliases for the
+// urls without a vanity alias are reserved together
+func (s *Service) CreateBatch(ctx context.Context, params []CreateParams) ([]*Url, error) {
+	tx, err := s.conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("start db transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	count := 0
+	for _, p := range params {
+		if p.Alias == "" {
+			count++
+		}
+	}
+	free := []string{}
+	if count > 0 {
+		free, err = s.alias.WithTx(tx).ReserveFree(ctx, count)
+		if err != nil {
+			return nil, fmt.Errorf("could reserve free aliases: %w", err)
+		}
+	}
+
+	db := s.db.WithTx(tx)
+	out := []*Url{}
+	for _, p := range params {
+		alias := p.Alias
+		if alias == "" {
+			alias, free = free[0], free[1:]
+		}
+		args := queries.CreateUrlParams{
+			ID:        p.ID.UUID(),
+			Alias:     alias,
+			Url:       p.Url,
+			Domain:    p.Domain,
+			ExpiresAt: nullTime(p.ExpiresAt),
+			MaxClicks: nullInt(p.MaxClicks),
+		}
+		if p.Owner != nil {
+			args.OwnerID = p.Owner.NullUUID()
+		}
+		url, err := db.CreateUrl(ctx, args)
+		if err != nil {
+			return nil, fmt.Errorf("store url: %w", err)
+		}
+		out = append(out, mapUrl(url))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit insert urls: %w", err)
+	}
+
+	s.notify(ctx, webhooks.UrlCreated, out...)
+	return out, nil
 }
 
 func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Url, error) {
@@ -94,6 +190,49 @@ func (s *Service) GetAlias(ctx context.Context, alias string) (*Url, error) {
 	return mapUrl(url), nil
 }
 
+// Returns the oldest url owned by the owner that redirects to the destination
+// and never expires, skipping quarantined urls
+func (s *Service) GetByDestination(
+	ctx context.Context,
+	url string,
+	owner *uuid.UUID,
+) (*Url, error) {
+	args := queries.GetUrlByDestinationParams{
+		Url: url,
+	}
+	if owner != nil {
+		args.OwnerID = owner.NullUUID()
+	}
+	out, err := s.db.GetUrlByDestination(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("get url by destination: %w", err)
+	}
+	return mapUrl(out), nil
+}
+
+// Disables the url, returns sql.ErrNoRows if it is already quarantined
+func (s *Service) Quarantine(ctx context.Context, id uuid.UUID, reason string) (*Url, error) {
+	url, err := s.db.QuarantineUrl(ctx, queries.QuarantineUrlParams{
+		ID:               id.UUID(),
+		QuarantinedAt:    sql.NullInt64{Int64: time.Now().Unix(), Valid: true},
+		QuarantineReason: sql.NullString{String: reason, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("quarantine url: %w", err)
+	}
+	return mapUrl(url), nil
+}
+
+// Lets a quarantined url redirect again, returns sql.ErrNoRows when it isn't
+// quarantined
+func (s *Service) Unquarantine(ctx context.Context, id uuid.UUID) (*Url, error) {
+	url, err := s.db.UnquarantineUrl(ctx, id.UUID())
+	if err != nil {
+		return nil, fmt.Errorf("unquarantine url: %w", err)
+	}
+	return mapUrl(url), nil
+}
+
 func (s *Service) Count(ctx context.Context) (int, error) {
 	count, err := s.db.CountUrls(ctx)
 	if err != nil {
@@ -102,4 +241,142 @@ func (s *Service) Count(ctx context.Context) (int, error) {
 	return int(count), nil
 }
 
+type ListParams struct {
+	// The id of the last url on the previous page
+	Cursor *uuid.UUID
+	Domain string
+	// Only return urls whose destination contains the string
+	Search        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Return the newest urls first
+	Desc  bool
+	Limit int
+	// Only return urls created by the api key
+	Owner *uuid.UUID
+}
+
+type UrlPage struct {
+	Urls []*Url
+	// The cursor for the next page, nil when there are no more results
+	Next *uuid.UUID
+}
+
+var (
+	likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+)
+
+func (s *Service) List(ctx context.Context, params ListParams) (*UrlPage, error) {
+	// Get an extra url so we know if there is another page
+	args := queries.ListUrlsParams{
+		PageSize: int32(params.Limit + 1),
+	}
+	if params.Owner != nil {
+		args.OwnerID = params.Owner.NullUUID()
+	}
+	if params.Cursor != nil {
+		args.Cursor = params.Cursor.NullUUID()
+	}
+	if params.Domain != "" {
+		args.Domain = sql.NullString{String: params.Domain, Valid: true}
+	}
+	if params.Search != "" {
+		args.Search = sql.NullString{String: likeEscaper.Replace(params.Search), Valid: true}
+	}
+	if params.CreatedAfter != nil {
+		args.CreatedAfter = uuid.MinOrderedAt(*params.CreatedAfter).NullUUID()
+	}
+	if params.CreatedBefore != nil {
+		args.CreatedBefore = uuid.MinOrderedAt(*params.CreatedBefore).NullUUID()
+	}
+
+	var rows []*queries.Url
+	var err error
+	if params.Desc {
+		rows, err = s.db.ListUrlsDesc(ctx, queries.ListUrlsDescParams(args))
+	} else {
+		rows, err = s.db.ListUrls(ctx, args)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list urls: %w", err)
+	}
+
+	page := &UrlPage{
+		Urls: mapUrls(rows),
+	}
+	if len(page.Urls) > params.Limit {
+		page.Urls = page.Urls[:params.Limit]
+		next := page.Urls[len(page.Urls)-1].ID
+		page.Next = &next
+	}
+	return page, nil
+}
+
+type UpdateParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+// Changes the destination of the url. The destination should already have been
+// screened, so a quarantined url is released.
+func (s *Service) Update(ctx context.Context, params UpdateParams) (*Url, error) {
+	url, err := s.db.UpdateUrl(ctx, queries.UpdateUrlParams{
+		ID:  params.ID.UUID(),
+		Url: params.Url,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update url: %w", err)
+	}
+	return mapUrl(url), nil
+}
+
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	deleted, err := s.db.DeleteUrl(ctx, id.UUID())
+	if err != nil {
+		return fmt.Errorf("delete url: %w", err)
+	}
+	s.notify(ctx, webhooks.UrlDeleted, mapUrl(deleted))
+	return nil
+}
+
+// Records a visit against the url, returning ErrExpired when it has passed its
+// expiry time or used up all of its clicks, or sql.ErrNoRows when it has been
+// deleted since it was fetched
+func (s *Service) Visit(ctx context.Context, url *Url) error {
+	if url.Expired() {
+		return ErrExpired
+	}
+	if url.MaxClicks == nil {
+		return nil
+	}
+	_, err := s.db.IncrementUrlVisits(ctx, queries.IncrementUrlVisitsParams{
+		ID:  url.ID.UUID(),
+		Now: time.Now().Unix(),
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("increment url visits: %w", err)
+		}
+		// Either its clicks are used up or it no longer exists
+		if _, err := s.db.GetUrl(ctx, url.ID.UUID()); err != nil {
+			return fmt.Errorf("get url: %w", err)
+		}
+		return ErrExpired
+	}
+	return nil
+}
+
+func (s *Service) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	deleted, err := s.db.DeleteExpiredUrls(ctx, nullTime(&before))
+	if err != nil {
+		return 0, fmt.Errorf("delete expired urls: %w", err)
+	}
+	expired := make([]*Url, 0, len(deleted))
+	for _, url := range deleted {
+		expired = append(expired, mapUrl(url))
+	}
+	s.notify(ctx, webhooks.UrlExpired, expired...)
+	return len(deleted), nil
+}
+
 var _ Urls = &Service{}
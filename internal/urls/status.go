@@ -0,0 +1,123 @@
+package urls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	gocache "github.com/henrywhitaker3/go-cache"
+	"github.com/henrywhitaker3/shorturl/internal/logger"
+	"github.com/henrywhitaker3/shorturl/internal/screening"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+)
+
+type CreateState string
+
+const (
+	StatePending CreateState = "pending"
+	StateCreated CreateState = "created"
+	StateFailed  CreateState = "failed"
+)
+
+type CreateStatus struct {
+	State CreateState `json:"state"`
+	// Why the last attempt to create the url failed
+	Reason string `json:"reason,omitempty"`
+	// The api key that requested the url
+	Owner *uuid.UUID `json:"owner,omitempty"`
+}
+
+// Tracks the state of queued url creations, so clients can tell a url that
+// is still being created from one that never will be
+type Statuses struct {
+	cache *gocache.Cache
+	ttl   time.Duration
+}
+
+type StatusesOpts struct {
+	Cache *gocache.Cache
+	// How long a status is kept for after it was last updated
+	TTL time.Duration
+}
+
+func NewStatuses(opts StatusesOpts) *Statuses {
+	return &Statuses{
+		cache: opts.Cache,
+		ttl:   opts.TTL,
+	}
+}
+
+func (s *Statuses) Pending(ctx context.Context, id uuid.UUID, owner *uuid.UUID) error {
+	return s.put(ctx, id, CreateStatus{State: StatePending, Owner: owner})
+}
+
+// Records a failed attempt to create the url. When final is false the job
+// will be retried, so the url stays pending.
+func (s *Statuses) Failed(ctx context.Context, id uuid.UUID, reason error, final bool) error {
+	status, err := s.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		status = &CreateStatus{}
+	}
+	status.State = StatePending
+	if final {
+		status.State = StateFailed
+	}
+	status.Reason = failureReason(ctx, reason)
+	return s.put(ctx, id, *status)
+}
+
+// Returns the reason shown to clients for a failed create, logging internal
+// errors instead of exposing them
+func failureReason(ctx context.Context, err error) string {
+	switch {
+	case errors.Is(err, screening.ErrBlocked):
+		return err.Error()
+	case errors.Is(err, ErrNoFreeAlias):
+		return ErrNoFreeAlias.Error()
+	case errors.Is(err, ErrAliasTaken):
+		return ErrAliasTaken.Error()
+	}
+	logger.Logger(ctx).Error("could not create url", "error", err)
+	return "could not create url"
+}
+
+func (s *Statuses) Created(ctx context.Context, id uuid.UUID) error {
+	status, err := s.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		status = &CreateStatus{}
+	}
+	status.State = StateCreated
+	status.Reason = ""
+	return s.put(ctx, id, *status)
+}
+
+// Returns the status of the url, or sql.ErrNoRows when there isn't one
+func (s *Statuses) Get(ctx context.Context, id uuid.UUID) (*CreateStatus, error) {
+	status := &CreateStatus{}
+	if err := s.cache.GetStruct(ctx, statusKey(id), status); err != nil {
+		if errors.Is(err, gocache.ErrMissingKey) {
+			return nil, fmt.Errorf("get create status: %w", sql.ErrNoRows)
+		}
+		return nil, fmt.Errorf("get create status: %w", err)
+	}
+	return status, nil
+}
+
+func (s *Statuses) put(ctx context.Context, id uuid.UUID, status CreateStatus) error {
+	if err := s.cache.PutStruct(ctx, statusKey(id), status, s.ttl); err != nil {
+		return fmt.Errorf("store create status: %w", err)
+	}
+	return nil
+}
+
+func statusKey(id uuid.UUID) string {
+	return fmt.Sprintf("urls:status:%s", id)
+}
@@ -0,0 +1,198 @@
+package urls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/internal/metrics"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/redis/rueidis"
+)
+
+var (
+	ErrTooManyStreams = errors.New("too many streams")
+)
+
+const (
+	// How long the count of a key's open streams is kept without being
+	// refreshed, so streams on a replica that died stop counting
+	streamCountTTL = time.Minute
+)
+
+// Only decrements counts that haven't expired, so a count is never left
+// negative without a ttl
+var releaseStream = rueidis.NewLuaScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("DECR", KEYS[1])
+end
+return 0
+`)
+
+// A stored click, as sent to live streams
+type ClickEvent struct {
+	Time     time.Time `json:"time"`
+	Country  string    `json:"country"`
+	Referrer string    `json:"referrer"`
+}
+
+func clickEvent(click StoreClick) ClickEvent {
+	return ClickEvent{
+		Time:     click.Time.UTC(),
+		Country:  click.Location.Country,
+		Referrer: click.Referrer,
+	}
+}
+
+// Fans out stored clicks to live streams on every replica using redis pub/sub
+type ClickStream struct {
+	redis     rueidis.Client
+	maxPerKey int
+	buffer    int
+}
+
+type ClickStreamOpts struct {
+	Redis rueidis.Client
+	// The most streams a single key can have open across every replica
+	MaxPerKey int
+	// How many events are queued for a stream before new events are dropped
+	Buffer int
+}
+
+func NewClickStream(opts ClickStreamOpts) *ClickStream {
+	return &ClickStream{
+		redis:     opts.Redis,
+		maxPerKey: opts.MaxPerKey,
+		buffer:    opts.Buffer,
+	}
+}
+
+func streamChannel(id uuid.UUID) string {
+	return fmt.Sprintf("clicks:stream:%s", id)
+}
+
+func streamCountKey(key string) string {
+	return fmt.Sprintf("clicks:stream:open:%s", key)
+}
+
+// Publishes the clicks to any streams for their urls
+func (s *ClickStream) Publish(ctx context.Context, clicks []StoreClick) error {
+	cmds := make(rueidis.Commands, 0, len(clicks))
+	for _, click := range clicks {
+		by, err := json.Marshal(clickEvent(click))
+		if err != nil {
+			return fmt.Errorf("marshal click event: %w", err)
+		}
+		cmds = append(
+			cmds,
+			s.redis.B().Publish().Channel(streamChannel(click.ID)).Message(string(by)).Build(),
+		)
+	}
+	for _, resp := range s.redis.DoMulti(ctx, cmds...) {
+		if err := resp.Error(); err != nil {
+			return fmt.Errorf("publish click event: %w", err)
+		}
+	}
+	return nil
+}
+
+// Subscribes to the clicks for the url, returning ErrTooManyStreams when the
+// key already has the maximum number of streams open. The events channel is
+// closed when the context is cancelled or the subscription fails, and stop
+// must be called once the stream is finished with. Events are dropped
+// instead of blocking when the stream can't keep up.
+func (s *ClickStream) Subscribe(
+	ctx context.Context,
+	key string,
+	id uuid.UUID,
+) (events <-chan *ClickEvent, stop func(), err error) {
+	if err := s.acquire(ctx, key); err != nil {
+		return nil, nil, err
+	}
+	metrics.ClickStreams.Inc()
+
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan *ClickEvent, s.buffer)
+	done := make(chan struct{})
+	go s.refresh(ctx, key)
+	go func() {
+		defer close(done)
+		defer close(out)
+		cmd := s.redis.B().Subscribe().Channel(streamChannel(id)).Build()
+		s.redis.Receive(ctx, cmd, func(msg rueidis.PubSubMessage) {
+			event := &ClickEvent{}
+			if err := json.Unmarshal([]byte(msg.Message), event); err != nil {
+				return
+			}
+			select {
+			case out <- event:
+			default:
+				metrics.ClickStreamEventsDropped.Inc()
+			}
+		})
+	}()
+
+	var once sync.Once
+	return out, func() {
+		once.Do(func() {
+			cancel()
+			<-done
+			s.release(key)
+			metrics.ClickStreams.Dec()
+		})
+	}, nil
+}
+
+// Counts the stream against the key's open streams in redis, so the limit
+// holds across every replica
+func (s *ClickStream) acquire(ctx context.Context, key string) error {
+	count := streamCountKey(key)
+	resps := s.redis.DoMulti(
+		ctx,
+		s.redis.B().Incr().Key(count).Build(),
+		s.redis.B().Expire().Key(count).Seconds(int64(streamCountTTL.Seconds())).Build(),
+	)
+	open, err := resps[0].AsInt64()
+	if err != nil {
+		return fmt.Errorf("could not count open streams: %w", err)
+	}
+	if err := resps[1].Error(); err != nil {
+		s.release(key)
+		return fmt.Errorf("could not count open streams: %w", err)
+	}
+	if open > int64(s.maxPerKey) {
+		s.release(key)
+		return ErrTooManyStreams
+	}
+	return nil
+}
+
+// Keeps the key's count from expiring while the stream is open
+func (s *ClickStream) refresh(ctx context.Context, key string) {
+	ticker := time.NewTicker(streamCountTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cmd := s.redis.B().Expire().Key(streamCountKey(key)).Seconds(int64(streamCountTTL.Seconds())).Build()
+			if err := s.redis.Do(ctx, cmd).Error(); err != nil && ctx.Err() == nil {
+				slog.Error("could not refresh open stream count", "error", err)
+			}
+		}
+	}
+}
+
+// Uses a new context, as the stream's context has usually been cancelled
+func (s *ClickStream) release(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := releaseStream.Exec(ctx, s.redis, []string{streamCountKey(key)}, nil).Error(); err != nil {
+		slog.Error("could not release open stream", "error", err)
+	}
+}
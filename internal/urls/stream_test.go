@@ -0,0 +1,85 @@
+package urls_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/geoip"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItStreamsClicks(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	stream := boiler.MustResolve[*urls.ClickStream](b)
+	id := uuid.MustNew()
+
+	events, stop, err := stream.Subscribe(ctx, "key", id)
+	require.Nil(t, err)
+	defer stop()
+
+	click := urls.StoreClick{
+		ID:       id,
+		Time:     time.Now().UTC().Truncate(time.Second),
+		Referrer: "https://example.com",
+		Location: geoip.Location{Country: "GB"},
+	}
+	// The subscription is set up in the background
+	require.Eventually(t, func() bool {
+		require.Nil(t, stream.Publish(ctx, []urls.StoreClick{click}))
+		select {
+		case event := <-events:
+			require.Equal(t, click.Time, event.Time)
+			require.Equal(t, "GB", event.Country)
+			require.Equal(t, "https://example.com", event.Referrer)
+			return true
+		case <-time.After(time.Millisecond * 100):
+			return false
+		}
+	}, time.Second*3, time.Millisecond*10)
+}
+
+func TestItLimitsStreamsPerKey(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	opts := urls.ClickStreamOpts{
+		Redis:     boiler.MustResolve[rueidis.Client](b),
+		MaxPerKey: 1,
+		Buffer:    1,
+	}
+	stream := urls.NewClickStream(opts)
+	// Another replica, which shares the limit
+	replica := urls.NewClickStream(opts)
+	id := uuid.MustNew()
+	key, otherKey := uuid.MustNew().String(), uuid.MustNew().String()
+
+	_, stop, err := stream.Subscribe(ctx, key, id)
+	require.Nil(t, err)
+
+	_, _, err = stream.Subscribe(ctx, key, id)
+	require.ErrorIs(t, err, urls.ErrTooManyStreams)
+	_, _, err = replica.Subscribe(ctx, key, id)
+	require.ErrorIs(t, err, urls.ErrTooManyStreams)
+
+	// Other keys have their own limit
+	_, other, err := stream.Subscribe(ctx, otherKey, id)
+	require.Nil(t, err)
+	defer other()
+
+	stop()
+	_, again, err := replica.Subscribe(ctx, key, id)
+	require.Nil(t, err)
+	defer again()
+}
@@ -1,26 +1,68 @@
 package urls
 
 import (
+	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/henrywhitaker3/shorturl/database/queries"
 	"github.com/henrywhitaker3/shorturl/internal/uuid"
 )
 
 type Url struct {
-	ID       uuid.UUID `json:"id"`
-	Alias    string    `json:"alias"`
-	Url      string    `json:"url"`
-	ShortUrl string    `json:"short_url"`
+	ID        uuid.UUID  `json:"id"`
+	Alias     string     `json:"alias"`
+	Url       string     `json:"url"`
+	ShortUrl  string     `json:"short_url"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxClicks *int       `json:"max_clicks,omitempty"`
+	// The api key that created the url
+	OwnerID *uuid.UUID `json:"-"`
+	// When the url was disabled because its destination was blocked
+	QuarantinedAt    *time.Time `json:"quarantined_at,omitempty"`
+	QuarantineReason string     `json:"quarantine_reason,omitempty"`
+}
+
+// Whether the url has passed its expiry time
+func (u *Url) Expired() bool {
+	if u.ExpiresAt == nil {
+		return false
+	}
+	return !time.Now().Before(*u.ExpiresAt)
+}
+
+// Whether the url has been disabled by screening
+func (u *Url) Quarantined() bool {
+	return u.QuarantinedAt != nil
 }
 
 func mapUrl(u *queries.Url) *Url {
-	return &Url{
-		ID:       uuid.UUID(u.ID),
-		Alias:    u.Alias,
-		Url:      u.Url,
-		ShortUrl: fmt.Sprintf("https://%s/%s", u.Domain, u.Alias),
+	out := &Url{
+		ID:        uuid.UUID(u.ID),
+		Alias:     u.Alias,
+		Url:       u.Url,
+		ShortUrl:  fmt.Sprintf("https://%s/%s", u.Domain, u.Alias),
+		CreatedAt: uuid.UUID(u.ID).Time(),
+	}
+	if u.ExpiresAt.Valid {
+		at := time.Unix(u.ExpiresAt.Int64, 0)
+		out.ExpiresAt = &at
+	}
+	if u.MaxClicks.Valid {
+		max := int(u.MaxClicks.Int64)
+		out.MaxClicks = &max
+	}
+	if u.OwnerID.Valid {
+		owner := uuid.UUID(u.OwnerID.UUID)
+		out.OwnerID = &owner
 	}
+	if u.QuarantinedAt.Valid {
+		at := time.Unix(u.QuarantinedAt.Int64, 0)
+		out.QuarantinedAt = &at
+		out.QuarantineReason = u.QuarantineReason.String
+	}
+	return out
 }
 
 func mapUrls(u []*queries.Url) []*Url {
@@ -30,3 +72,17 @@ func mapUrls(u []*queries.Url) []*Url {
 	}
 	return out
 }
+
+func nullTime(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.Unix(), Valid: true}
+}
+
+func nullInt(i *int) sql.NullInt64 {
+	if i == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*i), Valid: true}
+}
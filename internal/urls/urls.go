@@ -2,6 +2,7 @@ package urls
 
 import (
 	"context"
+	"time"
 
 	"github.com/henrywhitaker3/shorturl/internal/uuid"
 )
@@ -9,6 +10,15 @@ import (
 type Urls interface {
 	Count(context.Context) (int, error)
 	Create(context.Context, CreateParams) (*Url, error)
+	CreateBatch(context.Context, []CreateParams) ([]*Url, error)
 	Get(context.Context, uuid.UUID) (*Url, error)
 	GetAlias(context.Context, string) (*Url, error)
+	GetByDestination(context.Context, string, *uuid.UUID) (*Url, error)
+	List(context.Context, ListParams) (*UrlPage, error)
+	Update(context.Context, UpdateParams) (*Url, error)
+	Delete(context.Context, uuid.UUID) error
+	Quarantine(context.Context, uuid.UUID, string) (*Url, error)
+	Unquarantine(context.Context, uuid.UUID) (*Url, error)
+	Visit(context.Context, *Url) error
+	DeleteExpired(context.Context, time.Time) (int, error)
 }
@@ -0,0 +1,102 @@
+package urls
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/redis/rueidis"
+)
+
+const (
+	// The most days that can be counted at once
+	MaxVisitorDays = 366
+)
+
+// Counts approximate unique visitors per url per day using redis
+// HyperLogLogs, independently of the stored clicks
+type Visitors struct {
+	redis rueidis.Client
+	ttl   time.Duration
+}
+
+type VisitorsOpts struct {
+	Redis rueidis.Client
+	// How long the count for each day is kept
+	TTL time.Duration
+}
+
+func NewVisitors(opts VisitorsOpts) *Visitors {
+	return &Visitors{
+		redis: opts.Redis,
+		ttl:   opts.TTL,
+	}
+}
+
+// The keys for a url share a hash tag so they can be merged in a cluster
+func visitorsKey(id uuid.UUID, date time.Time) string {
+	return fmt.Sprintf("visitors:{%s}:%s", id, date.UTC().Format(time.DateOnly))
+}
+
+// Records a visit to the url by the visitor with the fingerprint
+func (v *Visitors) Add(ctx context.Context, id uuid.UUID, visitor string, at time.Time) error {
+	key := visitorsKey(id, at)
+	for _, resp := range v.redis.DoMulti(
+		ctx,
+		v.redis.B().Pfadd().Key(key).Element(visitor).Build(),
+		v.redis.B().Expire().Key(key).Seconds(int64(v.ttl.Seconds())).Build(),
+	) {
+		if err := resp.Error(); err != nil {
+			return fmt.Errorf("could not add visitor: %w", err)
+		}
+	}
+	return nil
+}
+
+// Counts the unique visitors to the url between the days of from and to,
+// including both
+func (v *Visitors) Count(ctx context.Context, id uuid.UUID, from, to time.Time) (int, error) {
+	day := time.Hour * 24
+	from = from.UTC().Truncate(day)
+	to = to.UTC().Truncate(day)
+	if to.Before(from) {
+		return 0, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from)/day >= MaxVisitorDays {
+		return 0, fmt.Errorf("can count at most %d days", MaxVisitorDays)
+	}
+
+	keys := []string{}
+	for date := from; !date.After(to); date = date.Add(day) {
+		keys = append(keys, visitorsKey(id, date))
+	}
+
+	if len(keys) == 1 {
+		count, err := v.redis.Do(ctx, v.redis.B().Pfcount().Key(keys[0]).Build()).AsInt64()
+		if err != nil {
+			return 0, fmt.Errorf("could not count visitors: %w", err)
+		}
+		return int(count), nil
+	}
+
+	// Merge the days into a temporary key. Fingerprints change every day, so
+	// a visitor on several days is counted once for each day
+	dest := fmt.Sprintf("visitors:{%s}:merge:%s", id, uuid.MustOrdered())
+	resps := v.redis.DoMulti(
+		ctx,
+		v.redis.B().Pfmerge().Destkey(dest).Sourcekey(keys...).Build(),
+		v.redis.B().Pfcount().Key(dest).Build(),
+		v.redis.B().Del().Key(dest).Build(),
+	)
+	for _, resp := range resps {
+		if err := resp.Error(); err != nil {
+			return 0, fmt.Errorf("could not count visitors: %w", err)
+		}
+	}
+	count, err := resps[1].AsInt64()
+	if err != nil {
+		return 0, fmt.Errorf("could not count visitors: %w", err)
+	}
+	return int(count), nil
+}
@@ -0,0 +1,49 @@
+package urls_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/henrywhitaker3/shorturl/internal/urls"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItCountsUniqueVisitors(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	visitors := boiler.MustResolve[*urls.Visitors](b)
+	id := uuid.MustNew()
+
+	today := time.Now().UTC().Truncate(time.Hour * 24)
+	yesterday := today.Add(-time.Hour * 24)
+
+	require.Nil(t, visitors.Add(ctx, id, "alice", yesterday))
+	require.Nil(t, visitors.Add(ctx, id, "bob", yesterday))
+	require.Nil(t, visitors.Add(ctx, id, "alice", today))
+	require.Nil(t, visitors.Add(ctx, id, "alice", today))
+	require.Nil(t, visitors.Add(ctx, id, "carol", today))
+
+	count, err := visitors.Count(ctx, id, today, today)
+	require.Nil(t, err)
+	require.Equal(t, 2, count)
+
+	count, err = visitors.Count(ctx, id, yesterday, yesterday)
+	require.Nil(t, err)
+	require.Equal(t, 2, count)
+
+	// The same visitor on both days is only counted once
+	count, err = visitors.Count(ctx, id, yesterday, today)
+	require.Nil(t, err)
+	require.Equal(t, 3, count)
+
+	count, err = visitors.Count(ctx, uuid.MustNew(), yesterday, today)
+	require.Nil(t, err)
+	require.Zero(t, count)
+}
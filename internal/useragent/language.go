@@ -0,0 +1,34 @@
+package useragent
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Returns the most preferred language from an Accept-Language header,
+// lowercased, or an empty string when there isn't one
+func Language(header string) string {
+	best := ""
+	weight := 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" || len(tag) > 35 {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > weight {
+			best = tag
+			weight = q
+		}
+	}
+	return best
+}
@@ -0,0 +1,122 @@
+package useragent
+
+import (
+	"strings"
+)
+
+type Device string
+
+const (
+	Desktop Device = "desktop"
+	Mobile  Device = "mobile"
+	Tablet  Device = "tablet"
+	Bot     Device = "bot"
+	Unknown Device = "unknown"
+)
+
+type Agent struct {
+	Browser string
+	OS      string
+	Device  Device
+	Bot     bool
+}
+
+type match struct {
+	token string
+	name  string
+}
+
+var (
+	// Tokens that identify crawlers, link previewers and scripted clients
+	bots = []string{
+		"bot", "crawl", "spider", "slurp", "facebookexternalhit", "embedly",
+		"preview", "headless", "curl/", "wget/", "python-requests", "python-urllib",
+		"go-http-client", "java/", "okhttp", "axios/", "node-fetch", "httpclient",
+		"libwww-perl", "scrapy", "lighthouse",
+	}
+
+	// Ordered so that browsers which include another browser's token in
+	// their user agent are matched first, e.g. Edge contains Chrome and Safari
+	browsers = []match{
+		{token: "edg", name: "Edge"},
+		{token: "opr/", name: "Opera"},
+		{token: "opera", name: "Opera"},
+		{token: "samsungbrowser/", name: "Samsung Internet"},
+		{token: "yabrowser/", name: "Yandex"},
+		{token: "firefox/", name: "Firefox"},
+		{token: "fxios/", name: "Firefox"},
+		{token: "crios/", name: "Chrome"},
+		{token: "chrome/", name: "Chrome"},
+		{token: "chromium/", name: "Chromium"},
+		{token: "msie ", name: "Internet Explorer"},
+		{token: "trident/", name: "Internet Explorer"},
+		{token: "safari/", name: "Safari"},
+	}
+
+	// iOS is matched before macOS as its user agents contain "like Mac OS X"
+	systems = []match{
+		{token: "windows", name: "Windows"},
+		{token: "iphone", name: "iOS"},
+		{token: "ipad", name: "iOS"},
+		{token: "ipod", name: "iOS"},
+		{token: "android", name: "Android"},
+		{token: "cros", name: "ChromeOS"},
+		{token: "mac os x", name: "macOS"},
+		{token: "macintosh", name: "macOS"},
+		{token: "linux", name: "Linux"},
+	}
+)
+
+// Parses a user agent header into the browser, operating system and device
+// class. Anything that can't be identified is reported as unknown.
+func Parse(ua string) Agent {
+	lower := strings.ToLower(strings.TrimSpace(ua))
+	if lower == "" {
+		return Agent{Browser: string(Unknown), OS: string(Unknown), Device: Unknown}
+	}
+
+	out := Agent{
+		Browser: find(lower, browsers),
+		OS:      find(lower, systems),
+		Bot:     isBot(lower),
+	}
+	out.Device = device(lower, out)
+	return out
+}
+
+func find(ua string, matches []match) string {
+	for _, m := range matches {
+		if strings.Contains(ua, m.token) {
+			return m.name
+		}
+	}
+	return string(Unknown)
+}
+
+func isBot(ua string) bool {
+	for _, token := range bots {
+		if strings.Contains(ua, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func device(ua string, agent Agent) Device {
+	switch {
+	case agent.Bot:
+		return Bot
+	case strings.Contains(ua, "ipad"),
+		strings.Contains(ua, "tablet"),
+		agent.OS == "Android" && !strings.Contains(ua, "mobile"):
+		return Tablet
+	case strings.Contains(ua, "mobi"),
+		strings.Contains(ua, "iphone"),
+		strings.Contains(ua, "ipod"):
+		return Mobile
+	case agent.OS == "Windows", agent.OS == "macOS", agent.OS == "Linux", agent.OS == "ChromeOS":
+		return Desktop
+	default:
+		return Unknown
+	}
+}
@@ -0,0 +1,92 @@
+package useragent_test
+
+import (
+	"testing"
+
+	"github.com/henrywhitaker3/shorturl/internal/useragent"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItParsesUserAgents(t *testing.T) {
+	tcs := []struct {
+		name  string
+		ua    string
+		agent useragent.Agent
+	}{
+		{
+			name:  "chrome on windows",
+			ua:    "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			agent: useragent.Agent{Browser: "Chrome", OS: "Windows", Device: useragent.Desktop},
+		},
+		{
+			name:  "edge on windows",
+			ua:    "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.51",
+			agent: useragent.Agent{Browser: "Edge", OS: "Windows", Device: useragent.Desktop},
+		},
+		{
+			name:  "safari on iphone",
+			ua:    "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			agent: useragent.Agent{Browser: "Safari", OS: "iOS", Device: useragent.Mobile},
+		},
+		{
+			name:  "safari on ipad",
+			ua:    "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			agent: useragent.Agent{Browser: "Safari", OS: "iOS", Device: useragent.Tablet},
+		},
+		{
+			name:  "firefox on macos",
+			ua:    "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0",
+			agent: useragent.Agent{Browser: "Firefox", OS: "macOS", Device: useragent.Desktop},
+		},
+		{
+			name:  "chrome on android phone",
+			ua:    "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+			agent: useragent.Agent{Browser: "Chrome", OS: "Android", Device: useragent.Mobile},
+		},
+		{
+			name:  "samsung browser on android tablet",
+			ua:    "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Safari/537.36",
+			agent: useragent.Agent{Browser: "Samsung Internet", OS: "Android", Device: useragent.Tablet},
+		},
+		{
+			name:  "googlebot",
+			ua:    "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			agent: useragent.Agent{Browser: "unknown", OS: "unknown", Device: useragent.Bot, Bot: true},
+		},
+		{
+			name:  "curl",
+			ua:    "curl/8.5.0",
+			agent: useragent.Agent{Browser: "unknown", OS: "unknown", Device: useragent.Bot, Bot: true},
+		},
+		{
+			name:  "empty",
+			ua:    "",
+			agent: useragent.Agent{Browser: "unknown", OS: "unknown", Device: useragent.Unknown},
+		},
+	}
+
+	for _, c := range tcs {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.agent, useragent.Parse(c.ua))
+		})
+	}
+}
+
+func TestItPicksThePreferredLanguage(t *testing.T) {
+	tcs := []struct {
+		header   string
+		language string
+	}{
+		{header: "en-GB,en;q=0.9", language: "en-gb"},
+		{header: "fr;q=0.5, de;q=0.8, *;q=0.1", language: "de"},
+		{header: "*", language: ""},
+		{header: "", language: ""},
+		{header: "en;q=bongo, es", language: "es"},
+	}
+
+	for _, c := range tcs {
+		t.Run(c.header, func(t *testing.T) {
+			require.Equal(t, c.language, useragent.Language(c.header))
+		})
+	}
+}
@@ -35,10 +35,33 @@ func (u *UUID) UnmarshalParam(s string) error {
 	return nil
 }
 
+func (u UUID) NullUUID() uuid.NullUUID {
+	return uuid.NullUUID{UUID: u.UUID(), Valid: true}
+}
+
+// Returns a null uuid when the id is nil
+func NullFrom(id *UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return id.NullUUID()
+}
+
 func (u UUID) String() string {
 	return u.UUID().String()
 }
 
+// Returns the time embedded in an ordered (v7) uuid
+func (u UUID) Time() time.Time {
+	milli := int64(u[0])<<40 |
+		int64(u[1])<<32 |
+		int64(u[2])<<24 |
+		int64(u[3])<<16 |
+		int64(u[4])<<8 |
+		int64(u[5])
+	return time.UnixMilli(milli)
+}
+
 func New() (UUID, error) {
 	id, err := uuid.NewRandom()
 	return UUID(id), err
@@ -62,6 +85,22 @@ func OrderedAt(at time.Time) (UUID, error) {
 	return uuid, nil
 }
 
+// Returns the lowest ordered uuid that can be generated at the given time, which
+// can be used as a bound when querying a range of ordered ids
+func MinOrderedAt(at time.Time) UUID {
+	var id UUID
+	milli := at.UnixMilli()
+	id[0] = byte(milli >> 40)
+	id[1] = byte(milli >> 32)
+	id[2] = byte(milli >> 24)
+	id[3] = byte(milli >> 16)
+	id[4] = byte(milli >> 8)
+	id[5] = byte(milli)
+	id[6] = 0x70
+	id[8] = 0x80
+	return id
+}
+
 // Copied from google uuid package
 func makeV7(time time.Time, uuid []byte) {
 	_ = uuid[15] // bounds check
@@ -58,3 +58,22 @@ func TestItOrderV7AtCorrectly(t *testing.T) {
 	require.Equal(t, firstId.String(), ids[0])
 	require.Equal(t, lastId.String(), ids[1])
 }
+
+func TestItBoundsOrderedIdsByTime(t *testing.T) {
+	at := time.Now().Add(-time.Minute)
+
+	min := MinOrderedAt(at)
+	id := Must(OrderedAt(at))
+	next := MinOrderedAt(at.Add(time.Millisecond))
+
+	ids := []string{next.String(), id.String(), min.String()}
+	slices.Sort(ids)
+
+	require.Equal(t, []string{min.String(), id.String(), next.String()}, ids)
+}
+
+func TestItGetsTheTimeFromOrderedIds(t *testing.T) {
+	at := time.Now().Add(-time.Hour)
+	id := Must(OrderedAt(at))
+	require.Equal(t, at.UnixMilli(), id.Time().UnixMilli())
+}
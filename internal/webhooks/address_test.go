@@ -0,0 +1,46 @@
+package webhooks_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/henrywhitaker3/shorturl/internal/webhooks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItOnlyAllowsPublicAddresses(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"1.1.1.1":          true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.0.0.1":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"::":               false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"::ffff:127.0.0.1": false,
+	} {
+		t.Run(ip, func(t *testing.T) {
+			require.Equal(t, public, webhooks.Public(netip.MustParseAddr(ip)))
+		})
+	}
+}
+
+func TestItRejectsUrlsForPrivateAddresses(t *testing.T) {
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://localhost/hook",
+	} {
+		t.Run(url, func(t *testing.T) {
+			require.ErrorIs(t, webhooks.CheckUrl(context.Background(), url), webhooks.ErrPrivateAddress)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/database/queries"
+	"github.com/henrywhitaker3/shorturl/internal/metrics"
+	"github.com/henrywhitaker3/shorturl/internal/queue"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Returns the hex HMAC-SHA256 of "<timestamp>.<payload>", keyed with the
+// webhook's secret. The timestamp is included so receivers can reject
+// replayed payloads.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Checks the signature of a payload in constant time
+func Verify(secret string, timestamp int64, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature))
+}
+
+// Sends queued deliveries to their webhook, recording each attempt
+type DeliveryHandler struct {
+	db          *queries.Queries
+	client      *http.Client
+	maxAttempts int
+}
+
+type DeliveryHandlerOpts struct {
+	DB *queries.Queries
+	// How long to wait for the endpoint to respond
+	Timeout time.Duration
+	// How many times a delivery is attempted before it is marked as failed
+	MaxAttempts int
+	// Allow deliveries to loopback, private and link-local addresses
+	AllowPrivate bool
+}
+
+func NewDeliveryHandler(opts DeliveryHandlerOpts) *DeliveryHandler {
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivate {
+		dialer.Control = dialPublic
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// A proxy would be dialled instead of the webhook, so its address would
+	// be checked instead
+	transport.Proxy = nil
+
+	return &DeliveryHandler{
+		db: opts.DB,
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: transport,
+			// Redirects would turn the POST into a GET, and could point at
+			// private addresses, so are treated as failures
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts: opts.MaxAttempts,
+	}
+}
+
+func (d *DeliveryHandler) Handle(ctx context.Context, payload []byte) error {
+	job := queue.WebhookJob{}
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("unmarshal webhook job: %w %w", err, asynq.SkipRetry)
+	}
+
+	delivery, err := d.db.GetWebhookDelivery(ctx, job.DeliveryID.UUID())
+	if err != nil {
+		// Deliveries are deleted with their webhook
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("get webhook delivery: %w", err)
+	}
+	if Status(delivery.Status) != Pending {
+		return nil
+	}
+	webhook, err := d.db.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("get webhook: %w", err)
+	}
+
+	code, sendErr := d.send(ctx, webhook, delivery)
+
+	status := Succeeded
+	final := false
+	if sendErr != nil {
+		status = Pending
+		if int(delivery.Attempts)+1 >= d.maxAttempts {
+			status = Failed
+			final = true
+		}
+	}
+	args := queries.UpdateWebhookDeliveryParams{
+		ID:        delivery.ID,
+		Status:    string(status),
+		UpdatedAt: time.Now().Unix(),
+	}
+	if code != 0 {
+		args.ResponseCode = sql.NullInt32{Int32: int32(code), Valid: true}
+	}
+	if sendErr != nil {
+		args.Error = sql.NullString{String: sendErr.Error(), Valid: true}
+	}
+	if err := d.db.UpdateWebhookDelivery(ctx, args); err != nil {
+		return fmt.Errorf("update webhook delivery: %w", err)
+	}
+	metrics.WebhookDeliveries.WithLabelValues(string(status)).Inc()
+
+	if sendErr != nil {
+		if final {
+			return fmt.Errorf("deliver webhook: %w %w", sendErr, asynq.SkipRetry)
+		}
+		return fmt.Errorf("deliver webhook: %w", sendErr)
+	}
+	return nil
+}
+
+// Posts the payload to the webhook, returning the response code if there was
+// a response
+func (d *DeliveryHandler) send(
+	ctx context.Context,
+	webhook *queries.Webhook,
+	delivery *queries.WebhookDelivery,
+) (int, error) {
+	payload := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "shorturl-webhooks")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Read some of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
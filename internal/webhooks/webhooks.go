@@ -0,0 +1,332 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/henrywhitaker3/shorturl/database/queries"
+	"github.com/henrywhitaker3/shorturl/internal/queue"
+	"github.com/henrywhitaker3/shorturl/internal/uuid"
+)
+
+type EventType string
+
+const (
+	UrlCreated EventType = "url.created"
+	UrlClicked EventType = "url.clicked"
+	UrlExpired EventType = "url.expired"
+	UrlDeleted EventType = "url.deleted"
+)
+
+func (e EventType) Valid() bool {
+	switch e {
+	case UrlCreated, UrlClicked, UrlExpired, UrlDeleted:
+		return true
+	default:
+		return false
+	}
+}
+
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Succeeded Status = "succeeded"
+	Failed    Status = "failed"
+)
+
+// An event sent to the webhooks of the owner, and the webhooks without one
+type Event struct {
+	ID   uuid.UUID `json:"id"`
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+	// The api key the event belongs to, nil when it isn't owned
+	Owner *uuid.UUID `json:"-"`
+}
+
+func NewEvent(kind EventType, owner *uuid.UUID, data any) *Event {
+	return &Event{
+		ID:    uuid.MustOrdered(),
+		Type:  kind,
+		Time:  time.Now().UTC(),
+		Data:  data,
+		Owner: owner,
+	}
+}
+
+type Webhook struct {
+	ID        uuid.UUID   `json:"id"`
+	Url       string      `json:"url"`
+	Events    []EventType `json:"events"`
+	CreatedAt time.Time   `json:"created_at"`
+	// Only returned when the webhook is created
+	Secret string `json:"secret,omitempty"`
+
+	OwnerID *uuid.UUID `json:"-"`
+}
+
+func mapWebhook(w *queries.Webhook) *Webhook {
+	out := &Webhook{
+		ID:        uuid.UUID(w.ID),
+		Url:       w.Url,
+		Events:    []EventType{},
+		CreatedAt: time.Unix(w.CreatedAt, 0),
+	}
+	for _, event := range w.Events {
+		out.Events = append(out.Events, EventType(event))
+	}
+	if w.OwnerID.Valid {
+		owner := uuid.UUID(w.OwnerID.UUID)
+		out.OwnerID = &owner
+	}
+	return out
+}
+
+type Delivery struct {
+	ID           uuid.UUID       `json:"id"`
+	WebhookID    uuid.UUID       `json:"webhook_id"`
+	Event        EventType       `json:"event"`
+	Payload      json.RawMessage `json:"payload"`
+	Status       Status          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	ResponseCode *int            `json:"response_code,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+func mapDelivery(d *queries.WebhookDelivery) *Delivery {
+	out := &Delivery{
+		ID:        uuid.UUID(d.ID),
+		WebhookID: uuid.UUID(d.WebhookID),
+		Event:     EventType(d.Event),
+		Payload:   json.RawMessage(d.Payload),
+		Status:    Status(d.Status),
+		Attempts:  int(d.Attempts),
+		Error:     d.Error.String,
+		CreatedAt: time.Unix(d.CreatedAt, 0),
+		UpdatedAt: time.Unix(d.UpdatedAt, 0),
+	}
+	if d.ResponseCode.Valid {
+		code := int(d.ResponseCode.Int32)
+		out.ResponseCode = &code
+	}
+	return out
+}
+
+type Webhooks struct {
+	db    *queries.Queries
+	queue *queue.Publisher
+}
+
+type WebhooksOpts struct {
+	DB *queries.Queries
+	// Used to queue deliveries, events aren't sent when nil
+	Queue *queue.Publisher
+}
+
+func New(opts WebhooksOpts) *Webhooks {
+	return &Webhooks{
+		db:    opts.DB,
+		queue: opts.Queue,
+	}
+}
+
+type CreateParams struct {
+	Url    string
+	Events []EventType
+	Owner  *uuid.UUID
+}
+
+// Registers a webhook, generating the secret its payloads are signed with
+func (w *Webhooks) Create(ctx context.Context, params CreateParams) (*Webhook, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generate webhook secret: %w", err)
+	}
+	events := []string{}
+	for _, event := range params.Events {
+		events = append(events, string(event))
+	}
+	webhook, err := w.db.CreateWebhook(ctx, queries.CreateWebhookParams{
+		ID:        uuid.MustOrdered().UUID(),
+		OwnerID:   uuid.NullFrom(params.Owner),
+		Url:       params.Url,
+		Secret:    secret,
+		Events:    events,
+		CreatedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("store webhook: %w", err)
+	}
+	out := mapWebhook(webhook)
+	out.Secret = secret
+	return out, nil
+}
+
+// Returns the webhook if it belongs to the owner, or sql.ErrNoRows
+func (w *Webhooks) Get(ctx context.Context, id uuid.UUID, owner *uuid.UUID) (*Webhook, error) {
+	webhook, err := w.db.GetWebhook(ctx, id.UUID())
+	if err != nil {
+		return nil, fmt.Errorf("get webhook: %w", err)
+	}
+	out := mapWebhook(webhook)
+	if !sameOwner(out.OwnerID, owner) {
+		return nil, fmt.Errorf("get webhook: %w", sql.ErrNoRows)
+	}
+	return out, nil
+}
+
+func (w *Webhooks) List(ctx context.Context, owner *uuid.UUID) ([]*Webhook, error) {
+	webhooks, err := w.db.ListWebhooks(ctx, uuid.NullFrom(owner))
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	out := []*Webhook{}
+	for _, webhook := range webhooks {
+		out = append(out, mapWebhook(webhook))
+	}
+	return out, nil
+}
+
+// Deletes the webhook and its deliveries if it belongs to the owner
+func (w *Webhooks) Delete(ctx context.Context, id uuid.UUID, owner *uuid.UUID) error {
+	deleted, err := w.db.DeleteWebhook(ctx, queries.DeleteWebhookParams{
+		ID:      id.UUID(),
+		OwnerID: uuid.NullFrom(owner),
+	})
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("delete webhook: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+type DeliveryPage struct {
+	Deliveries []*Delivery `json:"deliveries"`
+	// The cursor for the next page, nil on the last page
+	Next *uuid.UUID `json:"next"`
+}
+
+// Lists the deliveries for the webhook, newest first
+func (w *Webhooks) Deliveries(
+	ctx context.Context,
+	id uuid.UUID,
+	cursor *uuid.UUID,
+	limit int,
+) (*DeliveryPage, error) {
+	rows, err := w.db.ListWebhookDeliveries(ctx, queries.ListWebhookDeliveriesParams{
+		WebhookID: id.UUID(),
+		Cursor:    uuid.NullFrom(cursor),
+		PageSize:  int32(limit + 1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+
+	page := &DeliveryPage{Deliveries: []*Delivery{}}
+	for i, row := range rows {
+		if i == limit {
+			break
+		}
+		page.Deliveries = append(page.Deliveries, mapDelivery(row))
+	}
+	if len(rows) > limit {
+		next := page.Deliveries[len(page.Deliveries)-1].ID
+		page.Next = &next
+	}
+	return page, nil
+}
+
+// Queues a delivery of each event to the webhooks subscribed to it. Failures
+// are logged instead of returned, so sending events never fails the action
+// that caused them.
+func (w *Webhooks) Dispatch(ctx context.Context, events ...*Event) {
+	if w.queue == nil {
+		return
+	}
+	logger := slog.Default().With("subsystem", "webhooks")
+
+	// Events are usually sent in batches for the same owner, so only look up
+	// the webhooks once for each
+	subscribed := map[string][]*queries.Webhook{}
+	for _, event := range events {
+		key := string(event.Type)
+		if event.Owner != nil {
+			key += ":" + event.Owner.String()
+		}
+		webhooks, ok := subscribed[key]
+		if !ok {
+			var err error
+			webhooks, err = w.db.ListWebhooksForEvent(ctx, queries.ListWebhooksForEventParams{
+				OwnerID: uuid.NullFrom(event.Owner),
+				Event:   string(event.Type),
+			})
+			if err != nil {
+				logger.Error("could not list webhooks for event", "event", event.Type, "error", err)
+				continue
+			}
+			subscribed[key] = webhooks
+		}
+		if len(webhooks) == 0 {
+			continue
+		}
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			logger.Error("could not marshal event", "event", event.Type, "error", err)
+			continue
+		}
+		for _, webhook := range webhooks {
+			if err := w.deliver(ctx, webhook, event, payload); err != nil {
+				logger.Error("could not queue webhook delivery", "webhook", webhook.ID, "error", err)
+			}
+		}
+	}
+}
+
+func (w *Webhooks) deliver(
+	ctx context.Context,
+	webhook *queries.Webhook,
+	event *Event,
+	payload []byte,
+) error {
+	delivery, err := w.db.CreateWebhookDelivery(ctx, queries.CreateWebhookDeliveryParams{
+		ID:        uuid.MustOrdered().UUID(),
+		WebhookID: webhook.ID,
+		Event:     string(event.Type),
+		Payload:   string(payload),
+		Status:    string(Pending),
+		CreatedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("store delivery: %w", err)
+	}
+	return w.queue.Push(ctx, queue.WebhookTask, queue.WebhookJob{
+		DeliveryID: uuid.UUID(delivery.ID),
+	})
+}
+
+func sameOwner(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func generateSecret() (string, error) {
+	by := make([]byte, 32)
+	if _, err := rand.Read(by); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(by), nil
+}
@@ -0,0 +1,228 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/henrywhitaker3/boiler"
+	"github.com/henrywhitaker3/shorturl/database/queries"
+	"github.com/henrywhitaker3/shorturl/internal/queue"
+	"github.com/henrywhitaker3/shorturl/internal/test"
+	"github.com/henrywhitaker3/shorturl/internal/webhooks"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+)
+
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	payloads [][]byte
+}
+
+// Starts a server that checks each request is signed with the secret
+func receive(t *testing.T, status int, secret func() string) (*httptest.Server, *receiver) {
+	rec := &receiver{status: status}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.Nil(t, err)
+		ts, err := strconv.ParseInt(r.Header.Get(webhooks.TimestampHeader), 10, 64)
+		require.Nil(t, err)
+		require.True(t, webhooks.Verify(secret(), ts, body, r.Header.Get(webhooks.SignatureHeader)))
+
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.payloads = append(rec.payloads, body)
+		w.WriteHeader(rec.status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, rec
+}
+
+func deliver(
+	t *testing.T,
+	ctx context.Context,
+	handler *webhooks.DeliveryHandler,
+	delivery *webhooks.Delivery,
+) error {
+	payload, err := json.Marshal(queue.WebhookJob{DeliveryID: delivery.ID})
+	require.Nil(t, err)
+	return handler.Handle(ctx, payload)
+}
+
+func TestItDeliversWebhooks(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	hooks := boiler.MustResolve[*webhooks.Webhooks](b)
+	handler := webhooks.NewDeliveryHandler(webhooks.DeliveryHandlerOpts{
+		DB:           boiler.MustResolve[*queries.Queries](b),
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		AllowPrivate: true,
+	})
+
+	var hook *webhooks.Webhook
+	srv, rec := receive(t, http.StatusOK, func() string { return hook.Secret })
+
+	hook, err := hooks.Create(ctx, webhooks.CreateParams{
+		Url:    srv.URL,
+		Events: []webhooks.EventType{webhooks.UrlDeleted},
+	})
+	require.Nil(t, err)
+	require.NotEmpty(t, hook.Secret)
+
+	event := webhooks.NewEvent(webhooks.UrlDeleted, nil, map[string]string{"alias": "bongo"})
+	hooks.Dispatch(ctx, event)
+	// Not subscribed, so shouldn't create a delivery
+	hooks.Dispatch(ctx, webhooks.NewEvent(webhooks.UrlClicked, nil, nil))
+
+	page, err := hooks.Deliveries(ctx, hook.ID, nil, 10)
+	require.Nil(t, err)
+	require.Len(t, page.Deliveries, 1)
+	require.Equal(t, webhooks.Pending, page.Deliveries[0].Status)
+
+	require.Nil(t, deliver(t, ctx, handler, page.Deliveries[0]))
+
+	require.Len(t, rec.payloads, 1)
+	received := webhooks.Event{}
+	require.Nil(t, json.Unmarshal(rec.payloads[0], &received))
+	require.Equal(t, event.ID, received.ID)
+	require.Equal(t, webhooks.UrlDeleted, received.Type)
+
+	page, err = hooks.Deliveries(ctx, hook.ID, nil, 10)
+	require.Nil(t, err)
+	require.Equal(t, webhooks.Succeeded, page.Deliveries[0].Status)
+	require.Equal(t, 1, page.Deliveries[0].Attempts)
+	require.Equal(t, http.StatusOK, *page.Deliveries[0].ResponseCode)
+
+	// Redelivering a finished delivery is a no-op
+	require.Nil(t, deliver(t, ctx, handler, page.Deliveries[0]))
+	require.Len(t, rec.payloads, 1)
+}
+
+func TestItFailsDeliveriesAfterTheMaxAttempts(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	hooks := boiler.MustResolve[*webhooks.Webhooks](b)
+	handler := webhooks.NewDeliveryHandler(webhooks.DeliveryHandlerOpts{
+		DB:           boiler.MustResolve[*queries.Queries](b),
+		Timeout:      time.Second,
+		MaxAttempts:  2,
+		AllowPrivate: true,
+	})
+
+	var hook *webhooks.Webhook
+	srv, rec := receive(t, http.StatusInternalServerError, func() string { return hook.Secret })
+
+	hook, err := hooks.Create(ctx, webhooks.CreateParams{
+		Url:    srv.URL,
+		Events: []webhooks.EventType{webhooks.UrlExpired},
+	})
+	require.Nil(t, err)
+
+	hooks.Dispatch(ctx, webhooks.NewEvent(webhooks.UrlExpired, nil, nil))
+	page, err := hooks.Deliveries(ctx, hook.ID, nil, 10)
+	require.Nil(t, err)
+	require.Len(t, page.Deliveries, 1)
+
+	err = deliver(t, ctx, handler, page.Deliveries[0])
+	require.NotNil(t, err)
+	require.False(t, errors.Is(err, asynq.SkipRetry))
+
+	page, err = hooks.Deliveries(ctx, hook.ID, nil, 10)
+	require.Nil(t, err)
+	require.Equal(t, webhooks.Pending, page.Deliveries[0].Status)
+	require.Equal(t, 1, page.Deliveries[0].Attempts)
+
+	err = deliver(t, ctx, handler, page.Deliveries[0])
+	require.ErrorIs(t, err, asynq.SkipRetry)
+
+	page, err = hooks.Deliveries(ctx, hook.ID, nil, 10)
+	require.Nil(t, err)
+	require.Equal(t, webhooks.Failed, page.Deliveries[0].Status)
+	require.Equal(t, 2, page.Deliveries[0].Attempts)
+	require.Equal(t, http.StatusInternalServerError, *page.Deliveries[0].ResponseCode)
+	require.NotEmpty(t, page.Deliveries[0].Error)
+	require.Len(t, rec.payloads, 2)
+}
+
+func TestItDoesntDeliverToPrivateAddresses(t *testing.T) {
+	b := test.Boiler(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	hooks := boiler.MustResolve[*webhooks.Webhooks](b)
+	handler := webhooks.NewDeliveryHandler(webhooks.DeliveryHandlerOpts{
+		DB:          boiler.MustResolve[*queries.Queries](b),
+		Timeout:     time.Second,
+		MaxAttempts: 1,
+	})
+
+	var hook *webhooks.Webhook
+	srv, rec := receive(t, http.StatusOK, func() string { return hook.Secret })
+
+	hook, err := hooks.Create(ctx, webhooks.CreateParams{
+		Url:    srv.URL,
+		Events: []webhooks.EventType{webhooks.UrlDeleted},
+	})
+	require.Nil(t, err)
+
+	hooks.Dispatch(ctx, webhooks.NewEvent(webhooks.UrlDeleted, nil, nil))
+	page, err := hooks.Deliveries(ctx, hook.ID, nil, 10)
+	require.Nil(t, err)
+	require.Len(t, page.Deliveries, 1)
+
+	require.ErrorIs(t, deliver(t, ctx, handler, page.Deliveries[0]), asynq.SkipRetry)
+	require.Empty(t, rec.payloads)
+
+	page, err = hooks.Deliveries(ctx, hook.ID, nil, 10)
+	require.Nil(t, err)
+	require.Equal(t, webhooks.Failed, page.Deliveries[0].Status)
+	require.Contains(t, page.Deliveries[0].Error, webhooks.ErrPrivateAddress.Error())
+}
+
+func TestItOnlyDeletesOwnWebhooks(t *testing.T) {
+	b := test.Boiler(t)
+	key, _ := test.ApiKey(t, b)
+	other, _ := test.ApiKey(t, b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	hooks := boiler.MustResolve[*webhooks.Webhooks](b)
+	hook, err := hooks.Create(ctx, webhooks.CreateParams{
+		Url:    "https://example.com/hook",
+		Events: []webhooks.EventType{webhooks.UrlCreated},
+		Owner:  &key.ID,
+	})
+	require.Nil(t, err)
+
+	_, err = hooks.Get(ctx, hook.ID, &other.ID)
+	require.NotNil(t, err)
+	require.NotNil(t, hooks.Delete(ctx, hook.ID, &other.ID))
+	require.Nil(t, hooks.Delete(ctx, hook.ID, &key.ID))
+}
+
+func TestItSignsPayloads(t *testing.T) {
+	payload := []byte(`{"type":"url.created"}`)
+	sig := webhooks.Sign("secret", 1700000000, payload)
+
+	require.True(t, webhooks.Verify("secret", 1700000000, payload, sig))
+	require.False(t, webhooks.Verify("other", 1700000000, payload, sig))
+	require.False(t, webhooks.Verify("secret", 1700000001, payload, sig))
+	require.False(t, webhooks.Verify("secret", 1700000000, []byte(`{}`), sig))
+}